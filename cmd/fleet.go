@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"healthctl/pkg/k8s"
+	"healthctl/pkg/models"
+	"healthctl/pkg/report"
+	"healthctl/pkg/testsuite"
+)
+
+// defaultParallelClusters is the number of clusters checked at the same time in a fleet run
+const defaultParallelClusters = 4
+
+// errNoContexts is returned by runFleet when there is no context to run against
+var errNoContexts = errors.New("no contexts found in kubeconfig")
+
+// runFleet runs the checks selected by the filter against every context, at most
+// parallel clusters at a time, each with its own clients. progress, when set, is
+// called with the report of every cluster as soon as it is finished.
+func runFleet(ctx context.Context, contexts []string, filter testsuite.Filter, opts testsuite.RunOptions, parallel int, collectDetails bool, progress func(r *report.Report)) (*report.Fleet, error) {
+	if len(contexts) == 0 {
+		return nil, errNoContexts
+	}
+	if parallel <= 0 {
+		parallel = defaultParallelClusters
+	}
+	// the timeout also bounds reaching each cluster, before the runner fills in its defaults
+	if opts.Timeout <= 0 {
+		opts.Timeout = testsuite.DefaultRunOptions.Timeout
+	}
+	fleet := report.NewFleet()
+	reports := make([]*report.Report, len(contexts))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(parallel, len(contexts)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				reports[i] = runContext(ctx, fleet, contexts[i], filter, opts, collectDetails)
+				if progress != nil {
+					progress(reports[i])
+				}
+			}
+		}()
+	}
+	for i := range contexts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, r := range reports {
+		fleet.Add(r)
+	}
+	return fleet, nil
+}
+
+// runContext runs the selected checks against one context. A cluster that cannot
+// be reached gets a single error result for every selected suite.
+func runContext(ctx context.Context, fleet *report.Fleet, contextName string, filter testsuite.Filter, opts testsuite.RunOptions, collectDetails bool) *report.Report {
+	cluster := models.ClusterMetadata{Context: contextName}
+	kc, err := k8s.NewK8sClientForContext(contextName)
+	if err == nil {
+		pingCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+		err = kc.Ping(pingCtx)
+		cancel()
+	}
+	if err == nil {
+		metadataCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+		cluster, err = kc.GetClusterMetadata(metadataCtx)
+		cancel()
+	} else if kc != nil {
+		cluster.Cluster = kc.GetCurrentCluster()
+		cluster.APIServer = kc.RestConfig.Host
+	}
+	if err != nil {
+		r := fleet.NewReport(cluster)
+		for _, suite := range testsuite.Suites() {
+			if len(suiteChecks(filter, suite.Name)) == 0 {
+				continue
+			}
+			r.Add(suite.Name, []models.ResourceCheck{{
+				Label:    "Cluster",
+				Details:  fmt.Sprintf("Unable to reach context %s", contextName),
+				Outcome:  models.OutcomeError,
+				Severity: models.SeverityCritical,
+				Error:    err,
+			}})
+		}
+		return r
+	}
+
+	r := fleet.NewReport(cluster)
+	runSuites(ctx, testsuite.NewClients(kc), r, filter, opts)
+	if collectDetails {
+		r.CollectDetails(ctx, kc)
+	}
+	return r
+}
+
+// writeFleet prints the fleet run to stdout, writes the requested report files
+// and returns the exit code
+func writeFleet(fleet *report.Fleet, output, htmlPath, junitPath string) int {
+	if output == "text" {
+		printMatrix(os.Stdout, fleet)
+	} else if err := report.WriteFleet(os.Stdout, fleet, output); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s output: %v\n", output, err)
+		return exitError
+	}
+	if htmlPath != "" {
+		if err := report.WriteFleetHTMLFile(htmlPath, fleet); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing HTML report: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(os.Stderr, "HTML report written to %s\n", htmlPath)
+	}
+	if junitPath != "" {
+		if err := report.WriteFleetJUnitFile(junitPath, fleet); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JUnit report: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(os.Stderr, "JUnit report written to %s\n", junitPath)
+	}
+
+	if fleet.Failed() > 0 {
+		return exitFailed
+	}
+	return exitOK
+}
+
+// matrixCell formats the result of a suite on one cluster as verdict and passed/total
+func matrixCell(suite *report.SuiteResult) string {
+	if suite == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%s %d/%d", strings.ToUpper(string(suite.Verdict())), suite.Passed(), len(suite.Checks))
+}
+
+// printMatrix writes the cluster x suite matrix of the fleet run as a plain text table
+func printMatrix(out io.Writer, fleet *report.Fleet) {
+	suites := fleet.SuiteNames()
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "CONTEXT\tCLUSTER\tSTATUS\t%s\n", strings.ToUpper(strings.Join(suites, "\t")))
+	for _, r := range fleet.Reports {
+		cells := []string{}
+		for _, suite := range suites {
+			cells = append(cells, matrixCell(r.Suite(suite)))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Cluster.Context, r.Cluster.Cluster, strings.ToUpper(string(r.Verdict())), strings.Join(cells, "\t"))
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\nClusters: %d, Total Tests: %d, Passed: %d, Warnings: %d, Failed: %d, Errors: %d\n",
+		len(fleet.Reports), fleet.Total(), fleet.Count(models.OutcomePass), fleet.Count(models.OutcomeWarn), fleet.Count(models.OutcomeFail), fleet.Errors())
+}
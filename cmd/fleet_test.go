@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"healthctl/pkg/models"
+	"healthctl/pkg/testsuite"
+)
+
+func TestRunFleet(t *testing.T) {
+	testKubeconfig(t, map[string]string{"up": apiServer(t).URL, "down": unreachable(t)})
+	filter := testsuite.Filter{Suites: testsuite.SuiteNames(), Names: []string{"test-pass"}}
+
+	fleet, err := runFleet(context.Background(), []string{"up", "down"}, filter, testsuite.RunOptions{}, 2, false, nil)
+	if err != nil {
+		t.Fatalf("runFleet() error = %v", err)
+	}
+	if len(fleet.Reports) != 2 {
+		t.Fatalf("runFleet() returned %d reports, want 2", len(fleet.Reports))
+	}
+	for i, want := range []struct {
+		context string
+		outcome models.Outcome
+	}{
+		{"up", models.OutcomePass},
+		{"down", models.OutcomeError},
+	} {
+		r := fleet.Reports[i]
+		if r.Cluster.Context != want.context || r.RunID != fleet.RunID {
+			t.Errorf("report %d is of context %s, run %s, want %s, run %s", i, r.Cluster.Context, r.RunID, want.context, fleet.RunID)
+		}
+		if r.Total() != 1 || r.Count(want.outcome) != 1 {
+			t.Errorf("report of %s has %d checks, %d %s, want 1 %s", r.Cluster.Context, r.Total(), r.Count(want.outcome), want.outcome, want.outcome)
+		}
+	}
+}
+
+func TestRunFleetNoContexts(t *testing.T) {
+	_, err := runFleet(context.Background(), nil, testsuite.Filter{}, testsuite.RunOptions{}, 0, false, nil)
+	if !errors.Is(err, errNoContexts) {
+		t.Errorf("runFleet() error = %v, want %v", err, errNoContexts)
+	}
+}
+
+func TestRunCommandFleetExitCodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		servers map[string]string
+		args    []string
+		want    int
+	}{
+		{name: "all clusters passed", args: []string{"--contexts", "up", "--check", "test-pass"}, want: exitOK},
+		{name: "unreachable cluster", args: []string{"--all-contexts", "--check", "test-pass"}, want: exitFailed},
+		{name: "check failed", args: []string{"--contexts", "up", "--check", "test-fail", "--output", "json"}, want: exitFailed},
+		{name: "no contexts in kubeconfig", servers: map[string]string{}, args: []string{"--all-contexts", "--check", "test-pass"}, want: exitError},
+		{name: "contexts and all contexts", args: []string{"--contexts", "up", "--all-contexts"}, want: exitError},
+		{name: "context and contexts", args: []string{"--context", "up", "--contexts", "up,down"}, want: exitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servers := tt.servers
+			if servers == nil {
+				servers = map[string]string{"up": apiServer(t).URL, "down": unreachable(t)}
+			}
+			testKubeconfig(t, servers)
+			discardOutput(t)
+			if got := runCommand(append(tt.args, "--timeout", "5s")); got != tt.want {
+				t.Errorf("runCommand(%v) = %d, want %d", tt.args, got, tt.want)
+			}
+		})
+	}
+}
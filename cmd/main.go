@@ -4,14 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"healthctl/pkg/k8s"
 	"healthctl/pkg/models"
+	"healthctl/pkg/report"
 	"healthctl/pkg/testsuite"
 
 	"github.com/gdamore/tcell/v2"
@@ -29,6 +33,8 @@ type testInfoUI struct {
 	context   *tview.TableCell
 	nodes     *tview.TableCell
 	apiserver *tview.TableCell
+	results   map[string][]models.ResourceCheck
+	fleet     *report.Fleet
 }
 
 var Logo = []string{
@@ -42,18 +48,13 @@ var Logo = []string{
 	`                                         `,
 }
 
-var HEALTH_K8s = "K8s health"
-var HEALTH_INFRA = "Infra health"
-var HEALTH_PAAS = "PAAS health"
-var HEALTH_SMF = "SMF health"
-var HEALTH_UPF = "UPF health"
-var HEALTH_STORAGE = "Storage health"
 var ACTIVE_ALERTS = "Active Alerts"
 var HEALTH_REDIS = "Redis status"
 var COLLECT_KARGO = "Collect Kargo"
 var SET_DEBUG_LEVEL = "Set Debug Level"
 var FLUSH_REDIS = "Flush Redis"
 var RESOURCE_USAGE = "Resource Usage"
+var FLEET_HEALTH = "Fleet Health"
 
 func createApplication() (app *tview.Application) {
 	app = tview.NewApplication()
@@ -94,19 +95,10 @@ func createApplication() (app *tview.Application) {
 	afn_tools := tview.NewFlex()
 	afn_tools.SetDirection(tview.FlexRow)
 	afn_tools.SetBorder(true).SetTitle("Tools")
-	afn_tools.AddItem(CreateNewButton(HEALTH_K8s, sendCommand(pages, infoUI, HEALTH_K8s)), 0, 1, false)
-	afn_tools.AddItem(tview.NewBox(), 1, 0, false)
-
-	afn_tools.AddItem(CreateNewButton(HEALTH_INFRA, sendCommand(pages, infoUI, HEALTH_INFRA)), 0, 1, false)
-	afn_tools.AddItem(tview.NewBox(), 1, 0, false)
-	afn_tools.AddItem(CreateNewButton(HEALTH_PAAS, sendCommand(pages, infoUI, HEALTH_PAAS)), 0, 1, false)
-	afn_tools.AddItem(tview.NewBox(), 1, 0, false)
-	afn_tools.AddItem(CreateNewButton(HEALTH_SMF, sendCommand(pages, infoUI, HEALTH_SMF)), 0, 1, false)
-	afn_tools.AddItem(tview.NewBox(), 1, 0, false)
-	afn_tools.AddItem(CreateNewButton(HEALTH_UPF, sendCommand(pages, infoUI, HEALTH_UPF)), 0, 1, false)
-	afn_tools.AddItem(tview.NewBox(), 1, 0, false)
-	afn_tools.AddItem(CreateNewButton(HEALTH_STORAGE, sendCommand(pages, infoUI, HEALTH_STORAGE)), 0, 1, false)
-	afn_tools.AddItem(tview.NewBox(), 1, 0, false)
+	for _, suite := range testsuite.Suites() {
+		afn_tools.AddItem(CreateNewButton(suite.Title, sendCommand(pages, infoUI, suite)), 0, 1, false)
+		afn_tools.AddItem(tview.NewBox(), 1, 0, false)
+	}
 	afn_tools.AddItem(CreateNewButton(ACTIVE_ALERTS, Alerts(pages)), 0, 1, false)
 	afn_tools.AddItem(tview.NewBox(), 1, 0, false)
 	afn_tools.AddItem(CreateNewButton(HEALTH_REDIS, RedisStatus(pages)), 0, 1, false)
@@ -119,17 +111,107 @@ func createApplication() (app *tview.Application) {
 	afn_tools.AddItem(tview.NewBox(), 1, 0, false)
 	afn_tools.AddItem(CreateNewButton(RESOURCE_USAGE, DisplayResourceUsageReport(pages)), 0, 1, false)
 	afn_tools.AddItem(tview.NewBox(), 1, 0, false)
+	afn_tools.AddItem(CreateNewButton(FLEET_HEALTH, FleetHealth(pages, infoUI)), 0, 1, false)
+	afn_tools.AddItem(tview.NewBox(), 1, 0, false)
 
 	layout := createMainLayout(infoUI, logPanel, afn_tools, pages)
 	pages.AddPage("main", layout, true, true)
 
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyCtrlO {
+			openReport(infoUI)
+			return nil
+		}
+		if event.Key() == tcell.KeyCtrlS {
+			stop(infoUI)()
+			return nil
+		}
+		return event
+	})
+
 	app.SetRoot(pages, true).EnableMouse(true)
 	return app
 }
 
+// reportTimeout bounds the time spent reading object details for a report from the UI
+const reportTimeout = 2 * time.Minute
+
+// openReport writes the results of the suites run so far to an HTML report, and
+// the matrix of the last fleet run to a second one
+func openReport(infoUI *testInfoUI) {
+	if infoUI.fleet != nil {
+		fileName := infoUI.fleet.FileName("html")
+		if err := report.WriteFleetHTMLFile(fileName, infoUI.fleet); err != nil {
+			log.Printf("[red]Error writing fleet report: %v[-]\n", err)
+		} else {
+			log.Printf("[green]Fleet report written to %s[-]\n", fileName)
+		}
+		if len(infoUI.results) == 0 {
+			return
+		}
+	}
+	if len(infoUI.results) == 0 {
+		log.Println("[yellow]No test results yet, run a health suite before opening reports[-]")
+		return
+	}
+	r := report.New(models.ClusterMetadata{})
+	for _, suite := range testsuite.Suites() {
+		if checks, ok := infoUI.results[suite.Name]; ok {
+			r.Add(suite.Name, checks)
+		}
+	}
+	log.Println("Collecting report details, the report is written in the background")
+	// details are read from the cluster off the event loop, so a slow cluster cannot freeze the UI
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+		defer cancel()
+		message := writeReport(ctx, r)
+		infoUI.app.QueueUpdateDraw(func() {
+			log.Println(message)
+		})
+	}()
+}
+
+// writeReport fills in the cluster metadata and object details of the report and
+// writes it, returning the message to show in the output terminal
+func writeReport(ctx context.Context, r *report.Report) string {
+	kc, err := k8s.NewK8sClient()
+	if err != nil {
+		return fmt.Sprintf("[red]Error creating kubernetes client: %v[-]", err)
+	}
+	warning := ""
+	metadataCtx, cancel := context.WithTimeout(ctx, testsuite.DefaultRunOptions.Timeout)
+	cluster, err := kc.GetClusterMetadata(metadataCtx)
+	cancel()
+	if err != nil {
+		warning = fmt.Sprintf("[yellow]Unable to read cluster metadata: %v[-]\n", err)
+	}
+	r.Cluster = cluster
+	r.CollectDetails(ctx, kc)
+	fileName := r.FileName("html")
+	if err := report.WriteHTMLFile(fileName, r); err != nil {
+		return fmt.Sprintf("%s[red]Error writing report: %v[-]", warning, err)
+	}
+	return fmt.Sprintf("%s[green]Report written to %s[-]", warning, fileName)
+}
+
+// newClient creates the client of the selected context, reporting the error in the
+// output terminal when that fails
+func newClient() *k8s.K8sClient {
+	kc, err := k8s.NewK8sClient()
+	if err != nil {
+		log.Printf("[red]Error creating kubernetes client: %v[-]\n", err)
+		return nil
+	}
+	return kc
+}
+
 func SetDebugLevel(pages *tview.Pages) func() {
-	kc, _ := k8s.NewK8sClient()
 	return func() {
+		kc := newClient()
+		if kc == nil {
+			return
+		}
 		//open a new popup with a form to take input like namespace, podname, container name and debug level
 		form := tview.NewForm()
 		//form.SetBackgroundColor(tcell.ColorDarkCyan)
@@ -163,10 +245,10 @@ func SetDebugLevel(pages *tview.Pages) func() {
 			_, containerName := form.GetFormItemByLabel("Container").(*tview.DropDown).GetCurrentOption()
 			_, debugLevel := form.GetFormItemByLabel("Level").(*tview.DropDown).GetCurrentOption()
 			log.Printf("Setting Debug Level for %s/%s/%s to %s\n", namespace, podName, containerName, debugLevel)
-			if kc.SetDebugLevel(namespace, podName, containerName, debugLevel) {
-				log.Printf("[green]Debug Level set successfully for Container: %s Pod: %s Namespace: %s[-]\n", containerName, podName, namespace)
+			if err := kc.SetDebugLevel(context.Background(), namespace, podName, containerName, debugLevel); err != nil {
+				log.Printf("[red]Error setting Debug for Container: %s Pod: %s Namespace: %s: %v[-]\n", containerName, podName, namespace, tview.Escape(err.Error()))
 			} else {
-				log.Printf("[red]Error setting Debug for Container: %s Pod: %s Namespace: %s[-]\n", containerName, podName, namespace)
+				log.Printf("[green]Debug Level set successfully for Container: %s Pod: %s Namespace: %s[-]\n", containerName, podName, namespace)
 			}
 			pages.SwitchToPage("main")
 			pages.RemovePage("modal")
@@ -234,14 +316,18 @@ func CollectKargo(pages *tview.Pages) func() {
 }
 
 func executeKargoDump(config map[string]string) {
-	kc, _ := k8s.NewK8sClient()
-	kargoServiceIP, err := kc.GetKargoServiceIP()
+	kc := newClient()
+	if kc == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), testsuite.DefaultRunOptions.Timeout)
+	defer cancel()
+	kargoServiceIP, err := kc.GetKargoServiceIP(ctx)
 	if err != nil {
 		log.Println("Error getting Kargo service IP:", err)
 		return
 	}
-	kargoPort := "5555"
-	url := fmt.Sprintf("http://%s:%s/kargo/api/v1/collect", kargoServiceIP, kargoPort)
+	url := fmt.Sprintf("http://%s:%d/kargo/api/v1/collect", kargoServiceIP, kc.Config.Kargo.Port)
 
 	client := &http.Client{}
 	jsonData, err := json.Marshal(config)
@@ -260,13 +346,13 @@ func executeKargoDump(config map[string]string) {
 
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Println("Error sending HTTP request:", err)
+		log.Println("Error sending HTTP request:", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		fmt.Println("Error: received non-OK response status:", resp.Status)
+		log.Println("Error: received non-OK response status:", resp.Status)
 		return
 	}
 
@@ -274,46 +360,67 @@ func executeKargoDump(config map[string]string) {
 }
 
 func RedisStatus(pages *tview.Pages) func() {
-	kc, _ := k8s.NewK8sClient()
 	return func() {
 		clearLogPanel(pages)
-		redisStatus := kc.GetRedisStatus()
+		kc := newClient()
+		if kc == nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), testsuite.DefaultRunOptions.Timeout)
+		defer cancel()
+		redisStatus, err := kc.GetRedisStatus(ctx)
+		if err != nil {
+			log.Printf("[red]Unable to get redis status: %v[-]\n", tview.Escape(err.Error()))
+			return
+		}
 		displayRedisStatus(redisStatus)
 	}
 }
 
 func FlushRedis(pages *tview.Pages) func() {
-	kc, _ := k8s.NewK8sClient()
 	return func() {
 		clearLogPanel(pages)
-		size := kc.GetRedisDbSize()
-		for _, s := range size {
-			log.Printf("%s : %s \n", s.PodName, s.Output)
+		kc := newClient()
+		if kc == nil {
+			return
+		}
+		printDbSize := func() {
+			size, err := kc.GetRedisDbSize(context.Background())
+			for _, s := range size {
+				log.Printf("%s : %s \n", s.PodName, s.Output)
+			}
+			if err != nil {
+				log.Printf("[red]Unable to get redis db size: %v[-]\n", tview.Escape(err.Error()))
+			}
 		}
+		printDbSize()
 		log.Printf("[red:bl]Flushing Redis Data[-:-:-:-]\n")
-		err := kc.FlushRedisData()
+		err := kc.FlushRedisData(context.Background())
 		if err != nil {
-			log.Printf("[red:bl]Error Flushing Redis Data: %v[-:-:-:-]\n", err)
-		}
-		size = kc.GetRedisDbSize()
-		for _, s := range size {
-			log.Printf("%s : %s \n", s.PodName, s.Output)
+			log.Printf("[red:bl]Error Flushing Redis Data: %v[-:-:-:-]\n", tview.Escape(err.Error()))
 		}
+		printDbSize()
 	}
 }
 
 func GetSelectedCluster() string {
-	kc, _ := k8s.NewK8sClient()
+	kc, err := k8s.NewK8sClient()
+	if err != nil {
+		return "unknown"
+	}
 	return kc.GetCurrentCluster()
 }
 
 func Alerts(pages *tview.Pages) func() {
-	kc, _ := k8s.NewK8sClient()
 	return func() {
 		clearLogPanel(pages)
-		alertList := kc.GetAlerts()
-		if alertList == nil {
-			log.Println("[red]Unable to get alerts[-]")
+		kc := newClient()
+		if kc == nil {
+			return
+		}
+		alertList, err := kc.GetAlerts(context.Background())
+		if err != nil {
+			log.Printf("[red]Unable to get alerts: %v[-]\n", tview.Escape(err.Error()))
 			return
 		}
 		displayAlerts(alertList)
@@ -340,7 +447,7 @@ func displayRedisStatus(r k8s.RedisStatus) {
 		log.Printf("| %-191s |\n", "---- Redis cluster pods are [red]NOT[-] in n/n ready state ----")
 	}
 	newHyphenFormatter()
-	log.Printf("| %-191s |\n", fmt.Sprintf("cluster_state: %s", r.ClusterState))
+	log.Printf("| %-191s |\n", fmt.Sprintf("cluster_state: %t", r.ClusterState))
 	newHyphenFormatter()
 	log.Printf("| %-191s |\n", fmt.Sprintf("cluster_slots_ok: %d", r.ClusterSlotsOk))
 	newHyphenFormatter()
@@ -426,25 +533,60 @@ func createMainLayout(infoUI *testInfoUI, output tview.Primitive, afn_tools *tvi
 	///// Main Layout /////
 	metadata := createMetadataPanel(infoUI)
 
-	kc, _ := k8s.NewK8sClient()
-	config := k8s.GetClustersFromKubeConfig()
-	clusters := []string{}
-	for index, _ := range config.Clusters {
-		clusters = append(clusters, index)
+	contexts, err := k8s.Contexts()
+	if err != nil {
+		log.Printf("[red]Error reading kubeconfig contexts: %v[-]\n", err)
+	}
+	current := 0
+	active := ""
+	if kc := newClient(); kc != nil {
+		active = kc.GetCurrentContext()
 	}
+	for index, name := range contexts {
+		if name == active {
+			current = index
+		}
+	}
+	// selected is the context chosen last, metadata of earlier selections is dropped
+	selected := active
 	handler := func(text string, index int) {
-		kc.SetContext(config, text)
-		infoUI.context.SetText(config.CurrentContext)
-		infoUI.cluster.SetText(text)
-		nodes := kc.GetClusterNodes()
-		infoUI.nodes.SetText(fmt.Sprintf("Master: %d, Worker: %d", nodes[0], nodes[1]))
-		infoUI.apiserver.SetText(config.Clusters[text].Server)
+		if err := k8s.UseContext(text); err != nil {
+			log.Printf("[red]Error switching to context %s: %v[-]\n", text, err)
+			return
+		}
+		// results of the previous context must not end up in reports of this one
+		stop(infoUI)()
+		infoUI.ctx = nil
+		infoUI.results = map[string][]models.ResourceCheck{}
+		kc := newClient()
+		if kc == nil {
+			return
+		}
+		selected = text
 		pages.SwitchToPage("main")
+		// listing the nodes of an unreachable cluster must not block the UI
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), testsuite.DefaultRunOptions.Timeout)
+			defer cancel()
+			metadata, err := kc.GetClusterMetadata(ctx)
+			infoUI.app.QueueUpdateDraw(func() {
+				if selected != text {
+					return
+				}
+				if err != nil {
+					log.Printf("[yellow]Unable to read the nodes of context %s: %v[-]\n", text, err)
+				}
+				infoUI.context.SetText(metadata.Context)
+				infoUI.cluster.SetText(metadata.Cluster)
+				infoUI.nodes.SetText(fmt.Sprintf("Master: %d, Worker: %d", metadata.MasterNodes, metadata.WorkerNodes))
+				infoUI.apiserver.SetText(metadata.APIServer)
+			})
+		}()
 	}
 
 	form := tview.NewForm()
 	cluster := tview.NewDropDown()
-	cluster.SetOptions(clusters, handler).SetCurrentOption(0).SetFieldWidth(30).SetLabel("Cluster")
+	cluster.SetOptions(contexts, handler).SetCurrentOption(current).SetFieldWidth(30).SetLabel("Context")
 	form.AddFormItem(cluster).SetBorder(true).SetTitle("Cluster Selection")
 
 	commands := tview.NewTable()
@@ -540,67 +682,85 @@ func centerText(text string, width int) string {
 	return text
 }
 
-func runTests(selectedCommand string) {
-	kc, _ := k8s.NewK8sClient()
-	rl := []models.ResourceCheck{}
-	switch selectedCommand {
-	case HEALTH_K8s:
-		rl = testsuite.CheckK8s(kc.Client)
-		break
-	case HEALTH_INFRA:
-		rl = testsuite.CheckINFRA(kc.Client)
-		break
-	case HEALTH_PAAS:
-		rl = testsuite.CheckPAAS(kc.Client)
-		break
-	case HEALTH_SMF:
-		rl = testsuite.CheckSMF(kc.Client)
-		break
-	case HEALTH_UPF:
-		rl = testsuite.CheckUPF(kc.Client)
-		break
-	case HEALTH_STORAGE:
-		rl = testsuite.CheckStorage(kc.Client)
-		break
-	default:
-		log.Printf("Please select a test to run")
-	}
-
-	log.Printf("| %-5s | %-150s | %-7s |\n", "─────", "──────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────", "──────")
-	log.Printf("| %s | %s | %s  |\n", centerText("No.", 5), centerText("Test Summary", 150), centerText("Result", 7))
-	log.Printf("| %-5s | %-150s | %-7s |\n", "─────", "──────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────", "──────")
-
-	status := ""
+// outcomeColors maps check outcomes to the background color of the result cell
+var outcomeColors = map[models.Outcome]string{
+	models.OutcomePass:    "green",
+	models.OutcomeWarn:    "yellow",
+	models.OutcomeFail:    "red",
+	models.OutcomeError:   "darkred",
+	models.OutcomeSkipped: "grey",
+}
+
+// runTests runs the checks of the suite until they finish or ctx is cancelled.
+// It is called off the UI goroutine.
+func runTests(ctx context.Context, suite testsuite.Suite) []models.ResourceCheck {
+	kc, err := k8s.NewK8sClient()
+	if err != nil {
+		return []models.ResourceCheck{{
+			Label:    "Cluster",
+			Details:  "Unable to create the kubernetes client",
+			Outcome:  models.OutcomeError,
+			Severity: models.SeverityCritical,
+			Error:    err,
+		}}
+	}
+	rl, err := testsuite.RunSuite(ctx, testsuite.NewClients(kc), suite.Name, testsuite.DefaultRunOptions)
+	if err != nil {
+		log.Printf("[red]%v[-]\n", err)
+	}
+	return rl
+}
+
+// printTestResults writes the results table to the output terminal
+func printTestResults(rl []models.ResourceCheck) {
+	separator := func() {
+		log.Printf("| %s | %s | %s | %s | %s |\n", strings.Repeat("─", 5), strings.Repeat("─", 130), strings.Repeat("─", 8), strings.Repeat("─", 8), strings.Repeat("─", 7))
+	}
+	separator()
+	log.Printf("| %s | %s | %s | %s | %s |\n", centerText("No.", 5), centerText("Test Summary", 130), centerText("Severity", 8), centerText("Time", 8), centerText("Result", 7))
+	separator()
+
 	for index, resc := range rl {
-		if resc.Status {
-			status = "[:green::]PASS[:-::]"
-		} else {
-			status = "[:red::]FAIL[:-::]"
+		details := resc.Details
+		if resc.Error != nil {
+			details = fmt.Sprintf("%s: %v", resc.Details, resc.Error)
+		}
+		if len(details) > 130 {
+			details = details[:127] + "..."
 		}
-		log.Printf("| %s | %-150s | %-7s %s|\n", centerText(strconv.Itoa(index+1), 5), resc.Details, status, "   ")
-		log.Printf("| %-5s | %-150s | %-7s |\n", "─────", "──────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────", "──────")
+		status := fmt.Sprintf("[:%s::]%s[:-::]", outcomeColors[resc.Outcome], centerText(strings.ToUpper(string(resc.Outcome)), 7))
+		duration := resc.Duration.Round(time.Millisecond).String()
+		log.Printf("| %s | %-130s | %-8s | %8s | %s |\n", centerText(strconv.Itoa(index+1), 5), tview.Escape(details), resc.Severity, duration, status)
+		separator()
 	}
-	log.Printf("| %-5s | %s | %-7s |\n", "", centerText("Total Tests", 150), strconv.Itoa(len(rl)))
-	log.Printf("| %-5s | %-150s | %-7s |\n", "─────", "──────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────", "──────")
+	log.Printf("| %-5s | %s | %-8s | %-8s | %-7s |\n", "", centerText("Total Tests", 130), "", "", strconv.Itoa(len(rl)))
+	separator()
 }
 
-func sendCommand(pages *tview.Pages, infoUI *testInfoUI, selectedCommand string) func() {
+func sendCommand(pages *tview.Pages, infoUI *testInfoUI, suite testsuite.Suite) func() {
 	return func() {
-		startFunc := func(selectedCommand string) {
+		startFunc := func(suite testsuite.Suite) {
 			stop(infoUI)()
 			pages.SwitchToPage("main")
 			clearLogPanel(pages)
-			runTests(selectedCommand)
 			pages.RemovePage("modal")
 			ctx, cancel := context.WithCancel(context.Background())
 			infoUI.ctx = ctx
 			infoUI.cancel = cancel
+			log.Printf("Running %s checks, press ctrl+s to stop\n", suite.Title)
 			go func() {
-				defer func() {
-					cancel()
+				defer cancel()
+				rl := runTests(ctx, suite)
+				infoUI.app.QueueUpdate(func() {
+					// a newer run replaced this one, drop its results
+					if infoUI.ctx != ctx {
+						return
+					}
+					infoUI.results[suite.Name] = rl
+					printTestResults(rl)
 					infoUI.ctx = nil
-				}()
-				fmt.Println("In sendCommand")
+					infoUI.cancel = nil
+				})
 			}()
 		}
 
@@ -612,14 +772,14 @@ func sendCommand(pages *tview.Pages, infoUI *testInfoUI, selectedCommand string)
 		form := tview.NewForm()
 		form.SetBackgroundColor(tcell.ColorDarkSlateGray)
 		form.AddButton("Start", func() {
-			startFunc(selectedCommand)
+			startFunc(suite)
 		})
 		form.AddButton("Cancel", cancelFunc)
 		form.SetCancelFunc(cancelFunc)
 		form.SetButtonsAlign(tview.AlignCenter)
 
 		form.SetBorder(true).SetTitle("Confirmation")
-		form.AddTextView(fmt.Sprintf("Executing %s command on %s cluster", selectedCommand, GetSelectedCluster()), "", 0, 1, false, false)
+		form.AddTextView(fmt.Sprintf("Executing %s command on %s cluster", suite.Title, GetSelectedCluster()), "", 0, 1, false, false)
 
 		modal := createModalForm(pages, form, 13, 80)
 
@@ -628,10 +788,99 @@ func sendCommand(pages *tview.Pages, infoUI *testInfoUI, selectedCommand string)
 	}
 }
 
+// verdictColors maps verdicts to the background color of the matrix cells
+var verdictColors = map[report.Verdict]string{
+	report.VerdictGreen:   "green",
+	report.VerdictAmber:   "yellow",
+	report.VerdictRed:     "red",
+	report.VerdictUnknown: "grey",
+}
+
+// printFleetMatrix writes the cluster x suite matrix of the fleet run to the output
+// terminal, as printed by healthctl run
+func printFleetMatrix(fleet *report.Fleet) {
+	var matrix bytes.Buffer
+	printMatrix(&matrix, fleet)
+	log.Print(tview.Escape(matrix.String()))
+}
+
+// FleetHealth runs a suite, or all of them, against several contexts at once and
+// prints the cluster x suite matrix
+func FleetHealth(pages *tview.Pages, infoUI *testInfoUI) func() {
+	return func() {
+		allSuites := "All suites"
+		suiteOptions := append([]string{allSuites}, testsuite.SuiteNames()...)
+		selectedSuite := allSuites
+
+		cancelFunc := func() {
+			pages.SwitchToPage("main")
+			pages.RemovePage("modal")
+		}
+
+		form := tview.NewForm()
+		form.SetBackgroundColor(tcell.ColorDarkSlateGray)
+		form.AddInputField("Contexts", "", 60, nil, nil)
+		form.AddDropDown("Suite", suiteOptions, 0, func(option string, index int) {
+			selectedSuite = option
+		})
+		form.AddButton("Start", func() {
+			contexts := splitList(form.GetFormItemByLabel("Contexts").(*tview.InputField).GetText())
+			if len(contexts) == 0 {
+				all, err := k8s.Contexts()
+				if err != nil {
+					log.Printf("[red]Error reading kubeconfig contexts: %v[-]\n", err)
+					return
+				}
+				contexts = all
+			}
+			filter := testsuite.Filter{}
+			if selectedSuite != allSuites {
+				filter.Suites = []string{selectedSuite}
+			}
+
+			stop(infoUI)()
+			cancelFunc()
+			clearLogPanel(pages)
+			ctx, cancel := context.WithCancel(context.Background())
+			infoUI.ctx = ctx
+			infoUI.cancel = cancel
+			log.Printf("Running %s on %d contexts, press ctrl+s to stop\n", selectedSuite, len(contexts))
+			go func() {
+				defer cancel()
+				fleet, err := runFleet(ctx, contexts, filter, testsuite.DefaultRunOptions, defaultParallelClusters, false, func(r *report.Report) {
+					log.Printf(" [%s]●[-] %s finished: %d/%d passed\n", verdictColors[r.Verdict()], tview.Escape(r.Cluster.Context), r.Count(models.OutcomePass), r.Total())
+				})
+				infoUI.app.QueueUpdate(func() {
+					// a newer run replaced this one, drop its results
+					if infoUI.ctx != ctx {
+						return
+					}
+					infoUI.ctx = nil
+					infoUI.cancel = nil
+					if err != nil {
+						log.Printf("[red]Error running fleet checks: %v[-]\n", err)
+						return
+					}
+					infoUI.fleet = fleet
+					printFleetMatrix(fleet)
+				})
+			}()
+		})
+		form.AddButton("Cancel", cancelFunc)
+		form.SetCancelFunc(cancelFunc)
+		form.SetButtonsAlign(tview.AlignCenter)
+		form.SetBorder(true).SetTitle("Fleet Health")
+		form.AddTextView("", "Comma separated contexts, leave empty for all contexts of the kubeconfig", 0, 2, false, false)
+
+		modal := createModalForm(pages, form, 15, 90)
+		pages.AddPage("modal", modal, true, true)
+	}
+}
+
 func createInfoPanel(app *tview.Application) (infoUI *testInfoUI) {
 	infoPanel := tview.NewFlex().SetDirection(tview.FlexRow)
 
-	infoUI = &testInfoUI{}
+	infoUI = &testInfoUI{results: map[string][]models.ResourceCheck{}}
 	infoUI.app = app
 	infoUI.panel = infoPanel
 
@@ -668,7 +917,9 @@ func createTextViewPanel(app *tview.Application, name string) (panel *tview.Text
 func stop(infoUI *testInfoUI) func() {
 	return func() {
 		if infoUI.cancel != nil {
+			log.Println("[yellow]Stopping tests, checks still running are skipped[-]")
 			infoUI.cancel()
+			infoUI.cancel = nil
 		}
 	}
 }
@@ -697,9 +948,15 @@ func DisplayResourceUsageReport(pages *tview.Pages) func() {
 			log.Printf("────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────")
 		}
 		clearLogPanel(pages)
-		kc, _ := k8s.NewK8sClient()
-		kc.GetResourceUsageReport()
-		r := kc.GetResourceUsageReport()
+		kc := newClient()
+		if kc == nil {
+			return
+		}
+		r, err := kc.GetResourceUsageReport(context.Background(), k8s.NewSnapshot(kc.Client))
+		if err != nil {
+			log.Printf("[red]Unable to get resource usage: %v[-]\n", tview.Escape(err.Error()))
+			return
+		}
 
 		// log.Printf("| %s | %s | %s\n", centerText("Pod", 33), centerText("Container", 40), centerText("CPU/Memory", 40))
 
@@ -758,9 +1015,25 @@ func createProgressBarMemory(percentage float64, barLength int) string {
 }
 
 func main() {
+	flag.Parse()
+	if flag.NArg() > 0 {
+		switch flag.Arg(0) {
+		case "run":
+			os.Exit(runCommand(flag.Args()[1:]))
+		case "list":
+			os.Exit(listCommand(flag.Args()[1:]))
+		case "rbac":
+			os.Exit(rbacCommand(flag.Args()[1:]))
+		default:
+			fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+			os.Exit(exitError)
+		}
+	}
+
 	app := createApplication()
 
 	if err := app.Run(); err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitError)
 	}
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"healthctl/pkg/config"
+	"healthctl/pkg/k8s"
+)
+
+// rbacCommand implements "healthctl rbac", printing the ServiceAccount, ClusterRole
+// and ClusterRoleBinding needed to run inside the cluster, and optionally a CronJob
+func rbacCommand(args []string) int {
+	fs := flag.NewFlagSet("rbac", flag.ContinueOnError)
+	name := fs.String("name", "healthctl", "name of the service account, cluster role and binding")
+	namespace := fs.String("namespace", "healthctl", "namespace of the service account and cron job")
+	image := fs.String("image", "", "container image of healthctl; when set a CronJob running the checks is added")
+	schedule := fs.String("schedule", "0 * * * *", "schedule of the CronJob")
+	clusterName := fs.String("cluster-name", "", "cluster name passed to the CronJob for reports and config overrides")
+	fs.Var(flag.Lookup("config").Value, "config", flag.Lookup("config").Usage)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: healthctl rbac [--name healthctl] [--namespace healthctl] [--config file] [--image image [--schedule cron] [--cluster-name name] [-- run flags]]\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	manifests, err := k8s.RBACManifests(*name, *namespace, cfg, k8s.CronJobOptions{
+		Image:       *image,
+		Schedule:    *schedule,
+		ClusterName: *clusterName,
+		Args:        fs.Args(),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating manifests: %v\n", err)
+		return exitError
+	}
+	os.Stdout.Write(manifests)
+	return exitOK
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"healthctl/pkg/k8s"
+	"healthctl/pkg/models"
+	"healthctl/pkg/report"
+	"healthctl/pkg/testsuite"
+)
+
+// Exit codes of the headless commands
+const (
+	exitOK     = 0
+	exitFailed = 1
+	exitError  = 2
+)
+
+// runCommand implements "healthctl run", running suites without the terminal UI
+func runCommand(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	suites := fs.String("suite", "all", "comma separated list of suites to run ("+strings.Join(testsuite.SuiteNames(), ", ")+")")
+	checkNames := fs.String("check", "", "comma separated list of checks to run, as name or suite/name (see healthctl list)")
+	tags := fs.String("tag", "", "comma separated list of tags; only checks with one of them are run")
+	htmlPath := fs.String("html", "", "write a self-contained HTML report to this file")
+	output := fs.String("output", "text", "result format printed to stdout: text, json or yaml")
+	junitPath := fs.String("junit", "", "write a JUnit XML report to this file")
+	concurrency := fs.Int("concurrency", testsuite.DefaultRunOptions.Concurrency, "number of checks run at the same time")
+	timeout := fs.Duration("timeout", testsuite.DefaultRunOptions.Timeout, "maximum run time of a single check")
+	contexts := fs.String("contexts", "", "comma separated list of kubeconfig contexts to run against in parallel")
+	allContexts := fs.Bool("all-contexts", false, "run against every context of the kubeconfig")
+	parallel := fs.Int("parallel", defaultParallelClusters, "number of clusters checked at the same time with --contexts or --all-contexts")
+	// share the kubeconfig, context, cluster name and config flags with the k8s and config packages
+	fs.Var(flag.Lookup("kubeconfig").Value, "kubeconfig", flag.Lookup("kubeconfig").Usage)
+	fs.Var(flag.Lookup("context").Value, "context", flag.Lookup("context").Usage)
+	fs.Var(flag.Lookup("cluster-name").Value, "cluster-name", flag.Lookup("cluster-name").Usage)
+	fs.Var(flag.Lookup("config").Value, "config", flag.Lookup("config").Usage)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: healthctl run [--suite k8s,paas] [--check name] [--tag tag] [--concurrency n] [--timeout 30s] [--context name | --contexts a,b | --all-contexts] [--parallel n] [--kubeconfig path] [--cluster-name name] [--config file] [--html file] [--output text|json|yaml] [--junit file]\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+
+	names, err := parseSuites(*suites)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if *output != "text" && *output != report.FormatJSON && *output != report.FormatYAML {
+		fmt.Fprintf(os.Stderr, "unknown output format %q, valid formats are: text, json, yaml\n", *output)
+		return exitError
+	}
+
+	filter := testsuite.Filter{Suites: names, Names: splitList(*checkNames), Tags: splitList(*tags)}
+	checks := testsuite.Checks(filter)
+	if len(checks) == 0 {
+		fmt.Fprintln(os.Stderr, "no checks match the given --suite, --check and --tag")
+		return exitError
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	opts := testsuite.RunOptions{Concurrency: *concurrency, Timeout: *timeout}
+
+	contextNames := splitList(*contexts)
+	if *allContexts || len(contextNames) > 0 {
+		if *allContexts && len(contextNames) > 0 {
+			fmt.Fprintln(os.Stderr, "use either --contexts or --all-contexts")
+			return exitError
+		}
+		if flag.Lookup("context").Value.String() != "" {
+			fmt.Fprintln(os.Stderr, "--context cannot be combined with --contexts or --all-contexts")
+			return exitError
+		}
+		if *allContexts {
+			all, err := k8s.Contexts()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading kubeconfig contexts: %v\n", err)
+				return exitError
+			}
+			contextNames = all
+		}
+		fleet, err := runFleet(ctx, contextNames, filter, opts, *parallel, *htmlPath != "", nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error running fleet checks: %v\n", err)
+			return exitError
+		}
+		return writeFleet(fleet, *output, *htmlPath, *junitPath)
+	}
+
+	kc, err := k8s.NewK8sClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating kubernetes client: %v\n", err)
+		return exitError
+	}
+
+	metadataCtx, cancel := context.WithTimeout(ctx, *timeout)
+	cluster, err := kc.GetClusterMetadata(metadataCtx)
+	cancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reaching cluster %s: %v\n", kc.GetCurrentContext(), err)
+		return exitError
+	}
+
+	r := report.New(cluster)
+	runSuites(ctx, testsuite.NewClients(kc), r, filter, opts)
+
+	if *output == "text" {
+		printResults(os.Stdout, r)
+	} else if err := report.Write(os.Stdout, r, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s output: %v\n", *output, err)
+		return exitError
+	}
+	if *htmlPath != "" {
+		r.CollectDetails(ctx, kc)
+		if err := report.WriteHTMLFile(*htmlPath, r); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing HTML report: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(os.Stderr, "HTML report written to %s\n", *htmlPath)
+	}
+	if *junitPath != "" {
+		if err := report.WriteJUnitFile(*junitPath, r); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JUnit report: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(os.Stderr, "JUnit report written to %s\n", *junitPath)
+	}
+
+	if r.Failed() > 0 {
+		return exitFailed
+	}
+	return exitOK
+}
+
+// runSuites runs the checks selected by the filter suite by suite and adds their results to the report
+func runSuites(ctx context.Context, clients *testsuite.Clients, r *report.Report, filter testsuite.Filter, opts testsuite.RunOptions) {
+	for _, suite := range testsuite.Suites() {
+		checks := suiteChecks(filter, suite.Name)
+		if len(checks) == 0 {
+			continue
+		}
+		r.Add(suite.Name, testsuite.RunChecks(ctx, clients, checks, opts))
+	}
+}
+
+// suiteChecks returns the checks of the named suite selected by the filter
+func suiteChecks(filter testsuite.Filter, suite string) []testsuite.Check {
+	if !slices.Contains(filter.Suites, suite) {
+		return nil
+	}
+	return testsuite.Checks(testsuite.Filter{Suites: []string{suite}, Names: filter.Names, Tags: filter.Tags})
+}
+
+// parseSuites splits and validates the --suite value
+func parseSuites(value string) ([]string, error) {
+	names := []string{}
+	for _, name := range splitList(strings.ToLower(value)) {
+		if name == "all" {
+			return testsuite.SuiteNames(), nil
+		}
+		if _, ok := testsuite.LookupSuite(name); !ok {
+			return nil, fmt.Errorf("unknown suite %q, valid suites are: %s", name, strings.Join(testsuite.SuiteNames(), ", "))
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no suite selected")
+	}
+	return names, nil
+}
+
+// splitList splits a comma separated flag value, dropping empty items
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// listCommand implements "healthctl list", printing the registered checks
+func listCommand(args []string) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	suites := fs.String("suite", "all", "comma separated list of suites to list")
+	tags := fs.String("tag", "", "comma separated list of tags to filter on")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	names, err := parseSuites(*suites)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SUITE\tCHECK\tTAGS\tDESCRIPTION")
+	for _, check := range testsuite.Checks(testsuite.Filter{Suites: names, Tags: splitList(*tags)}) {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", check.Suite(), check.Name(), strings.Join(check.Tags(), ","), check.Description())
+	}
+	w.Flush()
+	return exitOK
+}
+
+// printResults writes a plain text table of the results
+func printResults(out io.Writer, r *report.Report) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SUITE\tCHECK\tRESULT\tDETAILS")
+	for _, suite := range r.Suites {
+		for _, resc := range suite.Checks {
+			status := strings.ToUpper(string(resc.Outcome))
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", suite.Name, resc.Label, status, resc.Details)
+		}
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\nTotal Tests: %d, Passed: %d, Warnings: %d, Failed: %d, Errors: %d\n",
+		r.Total(), r.Count(models.OutcomePass), r.Count(models.OutcomeWarn), r.Count(models.OutcomeFail), r.Errors())
+}
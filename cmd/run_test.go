@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"healthctl/pkg/models"
+	"healthctl/pkg/testsuite"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	testsuite.Register(
+		testsuite.NewCheck(testsuite.SuiteK8s, "test-pass", "Always passes", []string{"test"}, func(ctx context.Context, clients *testsuite.Clients) []models.ResourceCheck {
+			return []models.ResourceCheck{{Label: "pass", Details: "passed", Outcome: models.OutcomePass}}
+		}),
+		testsuite.NewCheck(testsuite.SuiteK8s, "test-fail", "Always fails", []string{"test"}, func(ctx context.Context, clients *testsuite.Clients) []models.ResourceCheck {
+			return []models.ResourceCheck{{Label: "fail", Details: "failed", Outcome: models.OutcomeFail}}
+		}),
+	)
+}
+
+// apiServer serves the version and the node list of a cluster with one node
+func apiServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/version":
+			fmt.Fprint(w, `{"major":"1","minor":"31","gitVersion":"v1.31.1"}`)
+		case "/api/v1/nodes":
+			json.NewEncoder(w).Encode(v1.NodeList{
+				TypeMeta: metav1.TypeMeta{Kind: "NodeList", APIVersion: "v1"},
+				Items:    []v1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "worker-0"}}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// setFlag sets a command line flag for the duration of the test
+func setFlag(t *testing.T, name, value string) {
+	t.Helper()
+	previous := flag.Lookup(name).Value.String()
+	if err := flag.Set(name, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { flag.Set(name, previous) })
+}
+
+// testKubeconfig points the kubeconfig flag at a kubeconfig with the given contexts,
+// each talking to its server, and an empty configuration file
+func testKubeconfig(t *testing.T, servers map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	kubeconfig := "apiVersion: v1\nkind: Config\nclusters:\n"
+	for name, server := range servers {
+		kubeconfig += fmt.Sprintf("- name: %s\n  cluster:\n    server: %s\n", name, server)
+	}
+	kubeconfig += "contexts:\n"
+	for name := range servers {
+		kubeconfig += fmt.Sprintf("- name: %s\n  context:\n    cluster: %s\n    user: test\n", name, name)
+	}
+	kubeconfig += "users:\n- name: test\n  user: {}\n"
+	if len(servers) == 0 {
+		kubeconfig = "apiVersion: v1\nkind: Config\n"
+	}
+
+	kubeconfigPath := filepath.Join(dir, "kubeconfig")
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(kubeconfigPath, []byte(kubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("{}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	setFlag(t, "kubeconfig", kubeconfigPath)
+	setFlag(t, "config", configPath)
+	setFlag(t, "context", "")
+}
+
+// discardOutput sends stdout and stderr to a file for the duration of the test
+func discardOutput(t *testing.T) {
+	t.Helper()
+	out, err := os.Create(filepath.Join(t.TempDir(), "output"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = out, out
+	t.Cleanup(func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		out.Close()
+	})
+}
+
+// unreachable returns the address of a server that is no longer listening
+func unreachable(t *testing.T) string {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	return server.URL
+}
+
+func TestRunCommandExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "checks passed", args: []string{"--context", "up", "--check", "k8s/test-pass"}, want: exitOK},
+		{name: "check failed", args: []string{"--context", "up", "--check", "test-pass,test-fail"}, want: exitFailed},
+		{name: "json output", args: []string{"--context", "up", "--tag", "test", "--output", "json"}, want: exitFailed},
+		{name: "unknown flag", args: []string{"--verbose"}, want: exitError},
+		{name: "unknown suite", args: []string{"--suite", "dns"}, want: exitError},
+		{name: "unknown output", args: []string{"--output", "xml"}, want: exitError},
+		{name: "no matching check", args: []string{"--check", "does-not-exist"}, want: exitError},
+		{name: "unreachable cluster", args: []string{"--context", "down", "--check", "test-pass"}, want: exitError},
+		{name: "unknown context", args: []string{"--context", "missing", "--check", "test-pass"}, want: exitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testKubeconfig(t, map[string]string{"up": apiServer(t).URL, "down": unreachable(t)})
+			discardOutput(t)
+			if got := runCommand(append(tt.args, "--timeout", "5s")); got != tt.want {
+				t.Errorf("runCommand(%v) = %d, want %d", tt.args, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package config
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultConfig is the built-in configuration, matching the standard fed-* layout
+//
+//go:embed default.yaml
+var defaultConfig []byte
+
+var configFlag *string
+
+func init() {
+	configFlag = flag.String("config", "", "(optional) path to the healthctl configuration file, defaults to ~/.healthctl/config.yaml")
+}
+
+// Config holds the namespaces, pods, ports and endpoints of the deployment
+type Config struct {
+	Namespaces   Namespaces   `json:"namespaces"`
+	Nodes        Nodes        `json:"nodes"`
+	Events       Events       `json:"events"`
+	Redis        Redis        `json:"redis"`
+	Alertmanager Alertmanager `json:"alertmanager"`
+	Kargo        Kargo        `json:"kargo"`
+	Debug        Debug        `json:"debug"`
+	SMFMonitor   SMFMonitor   `json:"smfMonitor"`
+	UPF          UPF          `json:"upf"`
+	Storage      Storage      `json:"storage"`
+	// CRDs lists the CustomResourceDefinitions the deployment depends on
+	CRDs []CRD `json:"crds"`
+	// Clusters holds partial configurations keyed by cluster or context name,
+	// applied on top of the rest of the file by ForCluster
+	Clusters map[string]json.RawMessage `json:"clusters,omitempty"`
+}
+
+// Namespaces of the components checked by the infra, PaaS and SMF suites
+type Namespaces struct {
+	OPA             string `json:"opa"`
+	MetalLB         string `json:"metallb"`
+	MetalLBServices string `json:"metallbServices"`
+	KubeAddons      string `json:"kubeAddons"`
+	RBAC            string `json:"rbac"`
+	Grafana         string `json:"grafana"`
+	Kibana          string `json:"kibana"`
+	Prometheus      string `json:"prometheus"`
+	Etcd            string `json:"etcd"`
+	Istio           string `json:"istio"`
+	KubeProm        string `json:"kubeProm"`
+	RedisOperator   string `json:"redisOperator"`
+	RedisCluster    string `json:"redisCluster"`
+	Jaeger          string `json:"jaeger"`
+	Elastic         string `json:"elastic"`
+	ElastAlert      string `json:"elastalert"`
+	Alerta          string `json:"alerta"`
+	Kiali           string `json:"kiali"`
+	SMF             string `json:"smf"`
+}
+
+// Nodes holds what the node check accepts as normal
+type Nodes struct {
+	// ExpectedTaints lists the taints nodes may carry, as key or key:effect
+	ExpectedTaints []string `json:"expectedTaints"`
+	// MaxKubeletSkew is the number of minor versions a kubelet may be behind the API server
+	MaxKubeletSkew int `json:"maxKubeletSkew"`
+	// HeartbeatTimeout is the age of the last node status update after which a node is stale
+	HeartbeatTimeout metav1.Duration `json:"heartbeatTimeout"`
+}
+
+// Events holds the window and the warning reasons of the event check
+type Events struct {
+	// Lookback is the age after which events are ignored
+	Lookback metav1.Duration `json:"lookback"`
+	// FailReasons are the warning reasons that fail the check, other warnings only warn
+	FailReasons []string `json:"failReasons"`
+	// Top is the number of noisiest objects listed
+	Top int `json:"top"`
+}
+
+// Redis locates the redis cluster and its custom resource
+type Redis struct {
+	Namespace      string `json:"namespace"`
+	Container      string `json:"container"`
+	Service        string `json:"service"`
+	Port           int    `json:"port"`
+	CustomResource string `json:"customResource"`
+	Group          string `json:"group"`
+	Version        string `json:"version"`
+	Resource       string `json:"resource"`
+}
+
+// Alertmanager locates the pod queried for active alerts
+type Alertmanager struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	URL       string `json:"url"`
+}
+
+// Kargo locates the service collecting debug dumps
+type Kargo struct {
+	Namespace string `json:"namespace"`
+	Service   string `json:"service"`
+	Port      int    `json:"port"`
+}
+
+// Debug holds the ports of the eTrace endpoint used to change debug levels
+type Debug struct {
+	Port           int            `json:"port"`
+	ContainerPorts map[string]int `json:"containerPorts,omitempty"`
+}
+
+// SMFMonitor locates the smfmonitor pod and its endpoint
+type SMFMonitor struct {
+	Namespace string `json:"namespace"`
+	Selector  string `json:"selector"`
+	Container string `json:"container"`
+	Port      int    `json:"port"`
+}
+
+// UPF locates the UPF workloads, its monitor endpoint and the networks of its interfaces
+type UPF struct {
+	Namespace string `json:"namespace"`
+	// Selector picks the UPF deployments and stateful sets, empty selects all of the namespace
+	Selector   string         `json:"selector"`
+	Monitor    UPFMonitor     `json:"monitor"`
+	Interfaces []UPFInterface `json:"interfaces"`
+}
+
+// UPFMonitor locates the upfmonitor pod and its endpoint
+type UPFMonitor struct {
+	Selector  string `json:"selector"`
+	Container string `json:"container"`
+	Port      int    `json:"port"`
+	Path      string `json:"path"`
+}
+
+// UPFInterface is a UPF interface served through a Multus network attachment
+type UPFInterface struct {
+	Name string `json:"name"`
+	// Selector picks the pods that must be attached to the network
+	Selector string `json:"selector"`
+	// Network is the NetworkAttachmentDefinition, as name or namespace/name
+	Network string `json:"network"`
+}
+
+// Storage locates the Rook/Ceph cluster and the data stores checked by the storage suite
+type Storage struct {
+	Ceph       Ceph        `json:"ceph"`
+	DataStores []DataStore `json:"dataStores"`
+	// VolumeUsageWarning and VolumeUsageCritical are the used percentages of a
+	// volume above which it is reported as a warning or a failure
+	VolumeUsageWarning  int `json:"volumeUsageWarning"`
+	VolumeUsageCritical int `json:"volumeUsageCritical"`
+}
+
+// Ceph locates the CephCluster custom resource and the Ceph daemons managed by Rook
+type Ceph struct {
+	Namespace   string `json:"namespace"`
+	Group       string `json:"group"`
+	Version     string `json:"version"`
+	Resource    string `json:"resource"`
+	OSDSelector string `json:"osdSelector"`
+	MonSelector string `json:"monSelector"`
+}
+
+// DataStore is a stateful data store, such as mongo or elastic, whose stateful
+// sets must be ready and whose claims must be bound
+type DataStore struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Selector picks the stateful sets of the data store, empty selects all of the namespace
+	Selector string `json:"selector"`
+}
+
+// CRD is a required CustomResourceDefinition and the versions it must serve
+type CRD struct {
+	// Name is the full name of the definition, resource.group
+	Name     string   `json:"name"`
+	Versions []string `json:"versions"`
+}
+
+// DefaultPath returns the location of the configuration file used when --config is not given
+func DefaultPath() string {
+	return filepath.Join(homedir.HomeDir(), ".healthctl", "config.yaml")
+}
+
+// Default returns the built-in configuration
+func Default() *Config {
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(defaultConfig, cfg); err != nil {
+		panic(fmt.Sprintf("invalid built-in configuration: %v", err))
+	}
+	return cfg
+}
+
+// Load returns the built-in configuration overlaid with the file given by
+// --config, or with ~/.healthctl/config.yaml when that file exists
+func Load() (*Config, error) {
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+	cfg := Default()
+	path := *configFlag
+	if path == "" {
+		path = DefaultPath()
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return cfg, nil
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	if err := overlay(cfg, data); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ForCluster returns the configuration with the overrides of the given cluster
+// and context names applied, in that order
+func (c *Config) ForCluster(names ...string) (*Config, error) {
+	cfg := *c
+	cfg.Debug.ContainerPorts = maps.Clone(c.Debug.ContainerPorts)
+	cfg.Nodes.ExpectedTaints = slices.Clone(c.Nodes.ExpectedTaints)
+	cfg.Events.FailReasons = slices.Clone(c.Events.FailReasons)
+	cfg.UPF.Interfaces = slices.Clone(c.UPF.Interfaces)
+	cfg.Storage.DataStores = slices.Clone(c.Storage.DataStores)
+	cfg.CRDs = slices.Clone(c.CRDs)
+	for i := range cfg.CRDs {
+		cfg.CRDs[i].Versions = slices.Clone(cfg.CRDs[i].Versions)
+	}
+	cfg.Clusters = nil
+	for _, name := range names {
+		override, ok := c.Clusters[name]
+		if !ok || name == "" {
+			continue
+		}
+		if err := overlay(&cfg, override); err != nil {
+			return nil, fmt.Errorf("parsing config overrides for cluster %s: %w", name, err)
+		}
+		if err := cfg.validate(); err != nil {
+			return nil, fmt.Errorf("invalid config overrides for cluster %s: %w", name, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// overlay decodes data on top of cfg. Settings missing from data keep their value,
+// lists given in data replace the lists of cfg as a whole.
+func overlay(cfg *Config, data []byte) error {
+	fields := map[string]any{}
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	resetLists(reflect.ValueOf(cfg).Elem(), fields)
+	return yaml.UnmarshalStrict(data, cfg)
+}
+
+// resetLists sets the list fields of the struct v that are given in fields to nil,
+// decoding into a list would otherwise only overwrite the fields of its elements
+func resetLists(v reflect.Value, fields map[string]any) {
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		value, ok := fields[name]
+		if !ok || name == "" {
+			continue
+		}
+		switch field := v.Field(i); field.Kind() {
+		case reflect.Slice:
+			field.SetZero()
+		case reflect.Struct:
+			if nested, ok := value.(map[string]any); ok {
+				resetLists(field, nested)
+			}
+		}
+	}
+}
+
+// validate reports the settings the checks cannot work with
+func (c *Config) validate() error {
+	var errs []error
+	if c.Events.Top < 0 {
+		errs = append(errs, fmt.Errorf("events.top must not be negative, got %d", c.Events.Top))
+	}
+	if c.Nodes.MaxKubeletSkew < 0 {
+		errs = append(errs, fmt.Errorf("nodes.maxKubeletSkew must not be negative, got %d", c.Nodes.MaxKubeletSkew))
+	}
+	warning, critical := c.Storage.VolumeUsageWarning, c.Storage.VolumeUsageCritical
+	if warning < 0 || critical > 100 || warning > critical {
+		errs = append(errs, fmt.Errorf("storage.volumeUsageWarning (%d) and storage.volumeUsageCritical (%d) must be percentages with warning <= critical", warning, critical))
+	}
+	return errors.Join(errs...)
+}
+
+// DebugPort returns the eTrace port of the container
+func (c *Config) DebugPort(container string) int {
+	if port, ok := c.Debug.ContainerPorts[container]; ok {
+		return port
+	}
+	return c.Debug.Port
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// loadFile runs Load with --config pointing to a file holding data
+func loadFile(t *testing.T, data string) (*Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	previous := *configFlag
+	*configFlag = path
+	t.Cleanup(func() { *configFlag = previous })
+	return Load()
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		check func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "empty file keeps the defaults",
+			data: "",
+			check: func(t *testing.T, cfg *Config) {
+				if !reflect.DeepEqual(cfg, Default()) {
+					t.Errorf("Load() = %+v, want the defaults", cfg)
+				}
+			},
+		},
+		{
+			name: "scalars are overlaid",
+			data: "namespaces:\n  grafana: monitoring\nkargo:\n  port: 8080\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Namespaces.Grafana != "monitoring" || cfg.Kargo.Port != 8080 {
+					t.Errorf("Load() grafana %q, kargo port %d", cfg.Namespaces.Grafana, cfg.Kargo.Port)
+				}
+				if cfg.Namespaces.OPA != Default().Namespaces.OPA || cfg.Kargo.Service != Default().Kargo.Service {
+					t.Errorf("Load() dropped the defaults next to the overlaid fields")
+				}
+			},
+		},
+		{
+			name: "crds are replaced",
+			data: "crds:\n  - name: foo.example.com\n",
+			check: func(t *testing.T, cfg *Config) {
+				want := []CRD{{Name: "foo.example.com"}}
+				if !reflect.DeepEqual(cfg.CRDs, want) {
+					t.Errorf("Load() crds = %+v, want %+v", cfg.CRDs, want)
+				}
+			},
+		},
+		{
+			name: "upf interfaces are replaced",
+			data: "upf:\n  interfaces:\n    - name: X\n",
+			check: func(t *testing.T, cfg *Config) {
+				want := []UPFInterface{{Name: "X"}}
+				if !reflect.DeepEqual(cfg.UPF.Interfaces, want) {
+					t.Errorf("Load() upf interfaces = %+v, want %+v", cfg.UPF.Interfaces, want)
+				}
+				if cfg.UPF.Monitor != Default().UPF.Monitor {
+					t.Errorf("Load() upf monitor = %+v, want the default", cfg.UPF.Monitor)
+				}
+			},
+		},
+		{
+			name: "data stores are replaced",
+			data: "storage:\n  dataStores:\n    - name: cassandra\n      namespace: fed-cassandra\n",
+			check: func(t *testing.T, cfg *Config) {
+				want := []DataStore{{Name: "cassandra", Namespace: "fed-cassandra"}}
+				if !reflect.DeepEqual(cfg.Storage.DataStores, want) {
+					t.Errorf("Load() data stores = %+v, want %+v", cfg.Storage.DataStores, want)
+				}
+				if cfg.Storage.Ceph != Default().Storage.Ceph {
+					t.Errorf("Load() ceph = %+v, want the default", cfg.Storage.Ceph)
+				}
+			},
+		},
+		{
+			name: "empty list clears the defaults",
+			data: "nodes:\n  expectedTaints: []\n",
+			check: func(t *testing.T, cfg *Config) {
+				if len(cfg.Nodes.ExpectedTaints) != 0 {
+					t.Errorf("Load() expected taints = %v, want none", cfg.Nodes.ExpectedTaints)
+				}
+				if cfg.Nodes.MaxKubeletSkew != Default().Nodes.MaxKubeletSkew {
+					t.Errorf("Load() max kubelet skew = %d, want the default", cfg.Nodes.MaxKubeletSkew)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadFile(t, tt.data)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{name: "unknown field", data: "namespace:\n  grafana: monitoring\n", err: "parsing config"},
+		{name: "negative top", data: "events:\n  top: -1\n", err: "events.top"},
+		{name: "negative kubelet skew", data: "nodes:\n  maxKubeletSkew: -2\n", err: "nodes.maxKubeletSkew"},
+		{name: "warning above critical", data: "storage:\n  volumeUsageWarning: 95\n", err: "storage.volumeUsageWarning"},
+		{name: "critical above 100", data: "storage:\n  volumeUsageCritical: 120\n", err: "storage.volumeUsageCritical"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadFile(t, tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Load() error = %v, want it to mention %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestForCluster(t *testing.T) {
+	base := Default()
+	base.Clusters = map[string]json.RawMessage{
+		"cluster": json.RawMessage(`{"namespaces":{"grafana":"monitoring"},"crds":[{"name":"x"}]}`),
+		"context": json.RawMessage(`{"namespaces":{"grafana":"grafana"},"kargo":{"port":8080}}`),
+	}
+
+	cfg, err := base.ForCluster("cluster", "context")
+	if err != nil {
+		t.Fatalf("ForCluster() error = %v", err)
+	}
+	if cfg.Namespaces.Grafana != "grafana" {
+		t.Errorf("ForCluster() grafana = %q, want the context override", cfg.Namespaces.Grafana)
+	}
+	if cfg.Kargo.Port != 8080 || cfg.Kargo.Service != base.Kargo.Service {
+		t.Errorf("ForCluster() kargo = %+v", cfg.Kargo)
+	}
+	if want := []CRD{{Name: "x"}}; !reflect.DeepEqual(cfg.CRDs, want) {
+		t.Errorf("ForCluster() crds = %+v, want %+v", cfg.CRDs, want)
+	}
+	if cfg.Clusters != nil {
+		t.Errorf("ForCluster() kept the cluster overrides")
+	}
+
+	if !reflect.DeepEqual(base.CRDs, Default().CRDs) || base.Namespaces.Grafana != Default().Namespaces.Grafana {
+		t.Errorf("ForCluster() changed the base config: %+v", base)
+	}
+
+	unknown, err := base.ForCluster("", "other")
+	if err != nil {
+		t.Fatalf("ForCluster() error = %v", err)
+	}
+	unknown.Clusters = base.Clusters
+	if !reflect.DeepEqual(unknown, base) {
+		t.Errorf("ForCluster() without overrides = %+v, want the base config", unknown)
+	}
+}
+
+func TestForClusterKeepsBase(t *testing.T) {
+	base := Default()
+	base.Clusters = map[string]json.RawMessage{
+		"a": json.RawMessage(`{"crds":[{"name":"x","versions":["v9"]}]}`),
+	}
+	cfg, err := base.ForCluster("a")
+	if err != nil {
+		t.Fatalf("ForCluster() error = %v", err)
+	}
+	if want := []CRD{{Name: "x", Versions: []string{"v9"}}}; !reflect.DeepEqual(cfg.CRDs, want) {
+		t.Errorf("ForCluster() crds = %+v, want %+v", cfg.CRDs, want)
+	}
+
+	// changes to the cluster config, nested lists included, must not reach the base
+	other, err := base.ForCluster("b")
+	if err != nil {
+		t.Fatalf("ForCluster() error = %v", err)
+	}
+	other.CRDs[0].Versions[0] = "v0"
+	other.UPF.Interfaces[0].Name = "X"
+	other.Debug.ContainerPorts["smf"] = 1
+	if !reflect.DeepEqual(base.CRDs, Default().CRDs) {
+		t.Errorf("base crds = %+v, want %+v", base.CRDs, Default().CRDs)
+	}
+	if !reflect.DeepEqual(base.UPF.Interfaces, Default().UPF.Interfaces) || len(base.Debug.ContainerPorts) != 0 {
+		t.Errorf("base config changed through a cluster config: %+v", base)
+	}
+}
+
+func TestForClusterInvalid(t *testing.T) {
+	base := Default()
+	base.Clusters = map[string]json.RawMessage{
+		"unknown":  json.RawMessage(`{"kargo":{"host":"x"}}`),
+		"negative": json.RawMessage(`{"events":{"top":-5}}`),
+	}
+	for name, want := range map[string]string{"unknown": "parsing config overrides", "negative": "events.top"} {
+		if _, err := base.ForCluster(name); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ForCluster(%q) error = %v, want it to mention %q", name, err, want)
+		}
+	}
+}
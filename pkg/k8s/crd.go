@@ -0,0 +1,79 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// crdResource is the CustomResourceDefinition resource of the apiextensions API
+var crdResource = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// CRDStatus is the state of a CustomResourceDefinition as reported by the API server
+type CRDStatus struct {
+	Name string
+	// Established is true once the API server serves the resource
+	Established bool
+	// NamesAccepted is false when the names of the resource conflict with another definition
+	NamesAccepted bool
+	// Served lists the versions the API server serves
+	Served []string
+	// Messages holds the messages of the conditions that are not true
+	Messages []string
+}
+
+// crd holds the part of the CustomResourceDefinition read by GetCRD
+type crd struct {
+	Spec struct {
+		Versions []struct {
+			Name   string `json:"name"`
+			Served bool   `json:"served"`
+		} `json:"versions"`
+	} `json:"spec"`
+	Status struct {
+		Conditions []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// GetCRD returns the state of the named CustomResourceDefinition, such as
+// redisclusters.db.ibm.com. A NotFound error means it is not installed.
+func (kc *K8sClient) GetCRD(ctx context.Context, name string) (CRDStatus, error) {
+	obj, err := kc.DynamicClient.Resource(crdResource).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return CRDStatus{}, err
+	}
+	raw, err := json.Marshal(obj.Object)
+	if err != nil {
+		return CRDStatus{}, err
+	}
+	var definition crd
+	if err := json.Unmarshal(raw, &definition); err != nil {
+		return CRDStatus{}, err
+	}
+
+	status := CRDStatus{Name: name}
+	for _, version := range definition.Spec.Versions {
+		if version.Served {
+			status.Served = append(status.Served, version.Name)
+		}
+	}
+	for _, condition := range definition.Status.Conditions {
+		ok := condition.Status == "True"
+		switch condition.Type {
+		case "Established":
+			status.Established = ok
+		case "NamesAccepted":
+			status.NamesAccepted = ok
+		}
+		if !ok && condition.Message != "" {
+			status.Messages = append(status.Messages, condition.Type+": "+condition.Message)
+		}
+	}
+	return status, nil
+}
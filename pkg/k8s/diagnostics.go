@@ -0,0 +1,148 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"healthctl/pkg/models"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/yaml"
+)
+
+// resourcesByKind maps the kinds reported by checks to their API resource, other
+// kinds are looked up through discovery
+var resourcesByKind = map[string]schema.GroupVersionResource{
+	"Node":                     {Version: "v1", Resource: "nodes"},
+	"Namespace":                {Version: "v1", Resource: "namespaces"},
+	"Pod":                      {Version: "v1", Resource: "pods"},
+	"Service":                  {Version: "v1", Resource: "services"},
+	"PersistentVolume":         {Version: "v1", Resource: "persistentvolumes"},
+	"PersistentVolumeClaim":    {Version: "v1", Resource: "persistentvolumeclaims"},
+	"Deployment":               {Group: "apps", Version: "v1", Resource: "deployments"},
+	"ReplicaSet":               {Group: "apps", Version: "v1", Resource: "replicasets"},
+	"StatefulSet":              {Group: "apps", Version: "v1", Resource: "statefulsets"},
+	"DaemonSet":                {Group: "apps", Version: "v1", Resource: "daemonsets"},
+	"Job":                      {Group: "batch", Version: "v1", Resource: "jobs"},
+	"CronJob":                  {Group: "batch", Version: "v1", Resource: "cronjobs"},
+	"Ingress":                  {Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
+	"StorageClass":             {Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"},
+	"CustomResourceDefinition": {Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"},
+}
+
+// kindCache holds the resources of the kinds served by the API server, read once
+// through discovery
+type kindCache struct {
+	mu        sync.Mutex
+	resources map[string]schema.GroupVersionResource
+}
+
+// lookup returns the preferred resource serving the kind. Discovery is retried on
+// the next lookup when it fails for every API group.
+func (c *kindCache) lookup(client discovery.DiscoveryInterface, kind string) (schema.GroupVersionResource, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resources == nil {
+		// the resources of the groups that could be read are returned along with the error
+		lists, err := discovery.ServerPreferredResources(client)
+		if len(lists) == 0 && err != nil {
+			return schema.GroupVersionResource{}, fmt.Errorf("discovering the resource of kind %s: %w", kind, err)
+		}
+		c.resources = map[string]schema.GroupVersionResource{}
+		for _, list := range lists {
+			gv, err := schema.ParseGroupVersion(list.GroupVersion)
+			if err != nil {
+				continue
+			}
+			for _, resource := range list.APIResources {
+				if _, ok := c.resources[resource.Kind]; ok || strings.Contains(resource.Name, "/") {
+					continue
+				}
+				c.resources[resource.Kind] = gv.WithResource(resource.Name)
+			}
+		}
+	}
+	gvr, ok := c.resources[kind]
+	if !ok {
+		return schema.GroupVersionResource{}, fmt.Errorf("unsupported kind %s", kind)
+	}
+	return gvr, nil
+}
+
+// resourceFor returns the API resource of the kind
+func (kc *K8sClient) resourceFor(kind string) (schema.GroupVersionResource, error) {
+	if gvr, ok := resourcesByKind[kind]; ok {
+		return gvr, nil
+	}
+	if kc.kinds == nil {
+		return schema.GroupVersionResource{}, fmt.Errorf("unsupported kind %s", kind)
+	}
+	return kc.kinds.lookup(kc.Client.Discovery(), kind)
+}
+
+// GetObjectYAML returns the YAML manifest of the referenced object without managed fields
+func (kc *K8sClient) GetObjectYAML(ctx context.Context, ref models.ObjectRef) (string, error) {
+	gvr, err := kc.resourceFor(ref.Kind)
+	if err != nil {
+		return "", err
+	}
+	obj, err := kc.DynamicClient.Resource(gvr).Namespace(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	obj.SetManagedFields(nil)
+	out, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// GetObjectEvents returns the events recorded for the referenced object, oldest first
+func (kc *K8sClient) GetObjectEvents(ctx context.Context, ref models.ObjectRef) ([]string, error) {
+	selector := fields.Set{
+		"involvedObject.kind": ref.Kind,
+		"involvedObject.name": ref.Name,
+	}.AsSelector().String()
+	events, err := kc.Client.CoreV1().Events(ref.Namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(events.Items, func(i, j int) bool {
+		return events.Items[i].LastTimestamp.Before(&events.Items[j].LastTimestamp)
+	})
+	lines := []string{}
+	for _, event := range events.Items {
+		lines = append(lines, fmt.Sprintf("%s %s %s (x%d): %s", event.LastTimestamp.Format("2006-01-02 15:04:05"), event.Type, event.Reason, event.Count, event.Message))
+	}
+	return lines, nil
+}
+
+// GetPodLogs returns the last lines of logs of every container in the pod
+func (kc *K8sClient) GetPodLogs(ctx context.Context, namespace, podName string, lines int64) (string, error) {
+	pod, err := kc.Client.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, container := range pod.Spec.Containers {
+		raw, err := kc.Client.CoreV1().Pods(namespace).GetLogs(podName, &v1.PodLogOptions{
+			Container: container.Name,
+			TailLines: &lines,
+		}).DoRaw(ctx)
+		fmt.Fprintf(&sb, "==> container %s <==\n", container.Name)
+		if err != nil {
+			fmt.Fprintf(&sb, "unable to fetch logs: %v\n", err)
+			continue
+		}
+		sb.Write(raw)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestKindCacheLookup(t *testing.T) {
+	discovery := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{}}
+	discovery.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "ceph.rook.io/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "cephclusters", Kind: "CephCluster", Namespaced: true, Verbs: []string{"get", "list"}},
+				{Name: "cephclusters/status", Kind: "CephCluster", Namespaced: true, Verbs: []string{"get"}},
+			},
+		},
+		{
+			GroupVersion: "autoscaling/v2",
+			APIResources: []metav1.APIResource{
+				{Name: "horizontalpodautoscalers", Kind: "HorizontalPodAutoscaler", Namespaced: true, Verbs: []string{"get", "list"}},
+			},
+		},
+	}
+
+	cache := &kindCache{}
+	tests := []struct {
+		kind string
+		want schema.GroupVersionResource
+		err  bool
+	}{
+		{kind: "CephCluster", want: schema.GroupVersionResource{Group: "ceph.rook.io", Version: "v1", Resource: "cephclusters"}},
+		{kind: "HorizontalPodAutoscaler", want: schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}},
+		{kind: "Unknown", err: true},
+	}
+	for _, tt := range tests {
+		got, err := cache.lookup(discovery, tt.kind)
+		if (err != nil) != tt.err {
+			t.Fatalf("lookup(%s) error = %v, want error %v", tt.kind, err, tt.err)
+		}
+		if got != tt.want {
+			t.Errorf("lookup(%s) = %v, want %v", tt.kind, got, tt.want)
+		}
+	}
+
+	// discovery runs once, later lookups are served from the cache
+	calls := len(discovery.Actions())
+	discovery.Resources = nil
+	if _, err := cache.lookup(discovery, "CephCluster"); err != nil {
+		t.Errorf("lookup() after discovery error = %v", err)
+	}
+	if len(discovery.Actions()) != calls {
+		t.Errorf("lookup() ran discovery again")
+	}
+}
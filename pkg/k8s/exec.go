@@ -0,0 +1,85 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// DefaultExecTimeout bounds commands run in containers when ctx has no deadline
+const DefaultExecTimeout = 30 * time.Second
+
+// ExitError is returned when a command ran in the container but exited with a
+// non-zero code. Stderr holds what the command wrote to stderr.
+type ExitError struct {
+	Pod      string
+	Command  string
+	ExitCode int
+	Stderr   string
+}
+
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("command %q in pod %s exited with code %d", e.Command, e.Pod, e.ExitCode)
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+// ExecuteRemoteCommand runs command with /bin/sh -c in the container and returns
+// its stdout and stderr. The error is an *ExitError when the command exited
+// non-zero, and any other error when it could not be run at all. The command is
+// stopped when ctx is done, or after DefaultExecTimeout when ctx has no deadline.
+func (kc *K8sClient) ExecuteRemoteCommand(ctx context.Context, namespace, pod, container, command string) (string, string, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultExecTimeout)
+		defer cancel()
+	}
+
+	request := kc.Client.CoreV1().RESTClient().
+		Post().
+		Namespace(namespace).
+		Resource("pods").
+		Name(pod).
+		SubResource("exec").
+		Param("container", container).
+		VersionedParams(&v1.PodExecOptions{
+			Command: []string{"/bin/sh", "-c", command},
+			Stdin:   false,
+			Stdout:  true,
+			Stderr:  true,
+			// no TTY, it would merge stderr into stdout
+			TTY: false,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(kc.RestConfig, "POST", request.URL())
+	if err != nil {
+		return "", "", fmt.Errorf("exec in pod %s/%s: %w", namespace, pod, err)
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	var exitErr utilexec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.Exited():
+		err = &ExitError{Pod: namespace + "/" + pod, Command: command, ExitCode: exitErr.ExitStatus(), Stderr: stderr.String()}
+	case ctx.Err() != nil:
+		err = fmt.Errorf("exec in pod %s/%s: %w", namespace, pod, ctx.Err())
+	default:
+		err = fmt.Errorf("exec in pod %s/%s: %w", namespace, pod, err)
+	}
+	return stdout.String(), stderr.String(), err
+}
@@ -5,99 +5,253 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"log"
+	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 	"time"
 
-	"bytes"
+	"healthctl/pkg/config"
+	"healthctl/pkg/models"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/client-go/util/homedir"
 
 	v1 "k8s.io/api/core/v1"
 
-	"k8s.io/client-go/kubernetes/scheme"
-
 	resource "k8s.io/apimachinery/pkg/api/resource"
-	"k8s.io/client-go/tools/remotecommand"
 	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
 var kubeconfig *string
 var contextFlag *string
+var clusterNameFlag *string
+
+// InClusterContext is the context name reported when running inside a pod with the
+// credentials of its service account
+const InClusterContext = "in-cluster"
+
+// activeContext is the context picked with UseContext; it is kept in memory only
+var (
+	activeContextMu sync.Mutex
+	activeContext   string
+)
 
 func init() {
 	if home := homedir.HomeDir(); home != "" {
 		kubeconfig = flag.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
-		contextFlag = flag.String("context", "", "")
+		contextFlag = flag.String("context", "", "(optional) kubeconfig context to use instead of the current context")
 	} else {
 		kubeconfig = flag.String("kubeconfig", "", "absolute path to the kubeconfig file")
-		contextFlag = flag.String("context", "", "")
+		contextFlag = flag.String("context", "", "(optional) kubeconfig context to use instead of the current context")
 	}
+	clusterNameFlag = flag.String("cluster-name", "", "(optional) cluster name used in reports and config overrides when running inside a pod")
 }
 
-type K8sClient struct {
-	Client        *kubernetes.Clientset
-	DynamicClient dynamic.Interface
-	MetricsClient *metrics.Clientset
+// parseFlags parses the command line unless a caller already did
+func parseFlags() {
+	if !flag.Parsed() {
+		flag.Parse()
+	}
 }
 
-func GetClustersFromKubeConfig() *clientcmdapi.Config {
-	flag.Parse()
-	config, err := clientcmd.LoadFromFile(*kubeconfig)
-	if err != nil {
-		panic(err.Error())
+// selectedContext returns the context picked with UseContext, else the --context flag;
+// empty means the current context of the kubeconfig
+func selectedContext() string {
+	parseFlags()
+	activeContextMu.Lock()
+	defer activeContextMu.Unlock()
+	if activeContext != "" {
+		return activeContext
 	}
-	return config
+	return *contextFlag
 }
 
-func CreateK8sClientSet() (*kubernetes.Clientset, error) {
+// InCluster reports whether healthctl runs inside a pod without a kubeconfig, in
+// which case the clients authenticate with the service account of the pod
+func InCluster() bool {
+	parseFlags()
+	if *contextFlag != "" {
+		return false
+	}
+	if *kubeconfig != "" {
+		if _, err := os.Stat(*kubeconfig); err == nil {
+			return false
+		}
+	}
+	return os.Getenv("KUBERNETES_SERVICE_HOST") != "" && os.Getenv("KUBERNETES_SERVICE_PORT") != ""
+}
+
+// inClusterName returns the cluster name used when running inside a pod
+func inClusterName() string {
+	if *clusterNameFlag != "" {
+		return *clusterNameFlag
+	}
+	return InClusterContext
+}
+
+// clientConfig returns the kubeconfig loader for the selected context
+func clientConfig() clientcmd.ClientConfig {
+	return clientConfigFor(selectedContext())
+}
 
-	flag.Parse()
-	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+// clientConfigFor returns the kubeconfig loader for the named context; an empty
+// name uses the current context of the kubeconfig
+func clientConfigFor(contextName string) clientcmd.ClientConfig {
+	parseFlags()
+	rules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: *kubeconfig}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: contextName}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+}
+
+// Contexts returns the names of all contexts of the kubeconfig, sorted. Inside a
+// pod the only context is InClusterContext.
+func Contexts() ([]string, error) {
+	if InCluster() {
+		return []string{InClusterContext}, nil
+	}
+	raw, err := clientConfig().RawConfig()
 	if err != nil {
-		panic(err.Error())
+		return nil, err
+	}
+	contexts := []string{}
+	for name := range raw.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+	return contexts, nil
+}
+
+// UseContext makes every client created afterwards talk to the named context.
+// The kubeconfig file is not modified.
+func UseContext(name string) error {
+	if InCluster() {
+		if name != InClusterContext {
+			return fmt.Errorf("context %q not available when running in-cluster", name)
+		}
+		return nil
 	}
-	return kubernetes.NewForConfig(config)
+	raw, err := clientConfig().RawConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := raw.Contexts[name]; !ok {
+		return fmt.Errorf("context %q not found in kubeconfig", name)
+	}
+	activeContextMu.Lock()
+	defer activeContextMu.Unlock()
+	activeContext = name
+	return nil
+}
+
+// buildConfig loads the rest config from the kubeconfig, honoring --context, or
+// from the service account when running inside a pod
+func buildConfig() (*rest.Config, error) {
+	if InCluster() {
+		return rest.InClusterConfig()
+	}
+	return clientConfig().ClientConfig()
+}
+
+// K8sClient holds the clients of a single kubeconfig context
+type K8sClient struct {
+	Client        *kubernetes.Clientset
+	DynamicClient dynamic.Interface
+	MetricsClient *metrics.Clientset
+	// RestConfig is the configuration the clients were built from
+	RestConfig *rest.Config
+	// Config locates the components of the deployment on the current cluster
+	Config *config.Config
+
+	contextName string
+	clusterName string
+	// kinds resolves the kinds of reported objects to their resource
+	kinds *kindCache
 }
 
 func CreateDynamicClientSet() (dynamic.Interface, error) {
-	flag.Parse()
-	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+	config, err := buildConfig()
 	if err != nil {
-		panic(err.Error())
+		return nil, err
 	}
 	return dynamic.NewForConfig(config)
 }
 
 func CreateMetricsClientSet() (*metrics.Clientset, error) {
-	flag.Parse()
-	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+	config, err := buildConfig()
 	if err != nil {
-		panic(err.Error())
+		return nil, err
 	}
 	return metrics.NewForConfig(config)
 }
 
+// NewK8sClient creates the clients of the selected context
 func NewK8sClient() (*K8sClient, error) {
-	client, err := CreateK8sClientSet()
+	return NewK8sClientForContext(selectedContext())
+}
+
+// NewK8sClientForContext creates the clients of the named context, independent of
+// the selected one; an empty name uses the current context of the kubeconfig, or
+// the service account when running inside a pod
+func NewK8sClientForContext(contextName string) (*K8sClient, error) {
+	if contextName == InClusterContext || (contextName == "" && InCluster()) {
+		restConfig, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, err
+		}
+		return newK8sClient(restConfig, InClusterContext, inClusterName())
+	}
+
+	loader := clientConfigFor(contextName)
+	raw, err := loader.RawConfig()
+	if err != nil {
+		return nil, err
+	}
+	if contextName == "" {
+		contextName = raw.CurrentContext
+	}
+	kubeContext, ok := raw.Contexts[contextName]
+	if !ok {
+		return nil, fmt.Errorf("context %q not found in kubeconfig", contextName)
+	}
+
+	restConfig, err := loader.ClientConfig()
 	if err != nil {
 		return nil, err
 	}
+	return newK8sClient(restConfig, contextName, kubeContext.Cluster)
+}
 
-	dynamicClient, err := CreateDynamicClientSet()
+// newK8sClient builds the clients of a context from its rest config and loads the
+// configuration with the overrides of the cluster and context
+func newK8sClient(restConfig *rest.Config, contextName, clusterName string) (*K8sClient, error) {
+	client, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	metricsClient, err := CreateMetricsClientSet()
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	metricsClient, err := metrics.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err = cfg.ForCluster(clusterName, contextName)
 	if err != nil {
 		return nil, err
 	}
@@ -106,79 +260,66 @@ func NewK8sClient() (*K8sClient, error) {
 		Client:        client,
 		DynamicClient: dynamicClient,
 		MetricsClient: metricsClient,
+		RestConfig:    restConfig,
+		Config:        cfg,
+		contextName:   contextName,
+		clusterName:   clusterName,
+		kinds:         &kindCache{},
 	}, nil
 }
 
-// GetClusterInfo returns the cluster version
-func (kc *K8sClient) GetClusterInfo() (string, error) {
-	clusterVersion, err := kc.Client.Discovery().ServerVersion()
-
-	if err != nil {
-		return "", err
-	}
-	return fmt.Sprintf("Cluster version: %s\n", clusterVersion), nil
+// Ping checks that the API server of the context answers
+func (kc *K8sClient) Ping(ctx context.Context) error {
+	return kc.Client.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error()
 }
 
-// Set context for the client
-func (kc *K8sClient) SetContext(config *clientcmdapi.Config, contextToSwitch string) {
-
-	for key, contexts := range config.Contexts {
-		if contexts.Cluster == contextToSwitch {
-			config.CurrentContext = key
-		}
-	}
-	// Write the config back to the kubeconfig file
-	clientcmd.ModifyConfig(clientcmd.NewDefaultPathOptions(), *config, false)
-	//load the client again with config
-	client, err := CreateK8sClientSet()
+// SetContext switches the client, and every client created afterwards, to the named
+// context. The switch is kept in memory, the kubeconfig file is not modified.
+func (kc *K8sClient) SetContext(contextName string) error {
+	//load the client first so a broken context leaves kc untouched
+	client, err := NewK8sClientForContext(contextName)
 	if err != nil {
-		panic(err.Error())
+		return err
 	}
-	kc.Client = client
-	dclient, err := CreateDynamicClientSet()
-	if err != nil {
-		panic(err.Error())
+	if err := UseContext(contextName); err != nil {
+		return err
 	}
-	kc.DynamicClient = dclient
-
-	metricsClient, err := CreateMetricsClientSet()
-	if err != nil {
-		panic(err.Error())
-	}
-	kc.MetricsClient = metricsClient
-
+	*kc = *client
+	return nil
 }
 
 func (kc *K8sClient) GetCurrentContext() string {
-	//get context from kubeconfig
-	config := GetClustersFromKubeConfig()
-	return config.CurrentContext
+	return kc.contextName
 }
 
 func (kc *K8sClient) GetCurrentCluster() string {
-	cluster := ""
-	config := GetClustersFromKubeConfig()
-	for key, contexts := range config.Contexts {
-		if key == config.CurrentContext {
-			cluster = contexts.Cluster
-		}
-	}
-	return cluster
+	return kc.clusterName
 }
 
-// Get Cluster Nodes names returns the cluster node name as a list
-func (kc *K8sClient) GetClusterNodesName() []string {
-	nodes, _ := kc.Client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
-	nodeNames := []string{}
-	for _, node := range nodes.Items {
-		nodeNames = append(nodeNames, node.Name)
+// GetClusterMetadata returns the context, cluster, node counts and apiserver of the
+// active context. When the nodes cannot be listed the metadata is returned without
+// node counts along with the error.
+func (kc *K8sClient) GetClusterMetadata(ctx context.Context) (models.ClusterMetadata, error) {
+	metadata := models.ClusterMetadata{
+		Context:   kc.contextName,
+		Cluster:   kc.clusterName,
+		APIServer: kc.RestConfig.Host,
+	}
+	nodes, err := kc.GetClusterNodes(ctx)
+	if err != nil {
+		return metadata, err
 	}
-	return nodeNames
+	metadata.MasterNodes = nodes[0]
+	metadata.WorkerNodes = nodes[1]
+	return metadata, nil
 }
 
-// GetClusterNodes returns the cluster nodes
-func (kc *K8sClient) GetClusterNodes() []int {
-	nodes, _ := kc.Client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+// GetClusterNodes returns the number of master and worker nodes
+func (kc *K8sClient) GetClusterNodes(ctx context.Context) ([]int, error) {
+	nodes, err := kc.Client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
 	masterNodes := 0
 	workerNodes := 0
 	for _, node := range nodes.Items {
@@ -188,7 +329,7 @@ func (kc *K8sClient) GetClusterNodes() []int {
 			workerNodes++
 		}
 	}
-	return []int{masterNodes, workerNodes}
+	return []int{masterNodes, workerNodes}, nil
 }
 
 // GetClusterNamespaces returns the cluster namespaces
@@ -221,7 +362,7 @@ func (kc *K8sClient) GetContainers(pod string) []string {
 	pods, err := kc.Client.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{
 		FieldSelector: fmt.Sprintf("metadata.name=%s", pod),
 	})
-	if err != nil {
+	if err != nil || len(pods.Items) == 0 {
 		return nil
 	}
 	for _, container := range pods.Items[0].Spec.Containers {
@@ -230,116 +371,6 @@ func (kc *K8sClient) GetContainers(pod string) []string {
 	return containerList
 }
 
-func GetAPIResources(client *kubernetes.Clientset) {
-	// Get all resources in the cluster
-	resources, err := client.Discovery().ServerPreferredResources()
-	if err != nil {
-		panic(err.Error())
-	}
-	for _, resource := range resources {
-		fmt.Printf("Resource: %s\n", resource.GroupVersion)
-		for _, apiResource := range resource.APIResources {
-			fmt.Printf("  Name: %s\n", apiResource.Name)
-			fmt.Printf("  Namespaced: %t\n", apiResource.Namespaced)
-			fmt.Printf("  Kind: %s\n", apiResource.Kind)
-			fmt.Printf("  Verbs: %s\n", apiResource.Verbs)
-		}
-	}
-
-}
-
-type TestStatus struct {
-	Status bool
-	Info   string
-	Error  error
-}
-
-// Check if all nodes are ready
-func (kc *K8sClient) CheckNodes() TestStatus {
-	nodes, err := kc.Client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
-	if err != nil {
-		return TestStatus{
-			Status: false,
-			Info:   "",
-			Error:  err}
-	}
-	for _, node := range nodes.Items {
-		for _, condition := range node.Status.Conditions {
-			if condition.Type == "Ready" && condition.Status != "True" {
-				return TestStatus{
-					Status: false,
-					Info:   fmt.Sprintf("Node %s is not ready\n", node.Name),
-					Error:  nil}
-			}
-		}
-	}
-	return TestStatus{
-		Status: true,
-		Info:   "All nodes are ready",
-		Error:  nil}
-}
-
-// Check if all pods are running
-func (kc *K8sClient) CheckPods() TestStatus {
-	pods, err := kc.Client.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{})
-	if err != nil {
-		return TestStatus{
-			Status: false,
-			Info:   "",
-			Error:  err}
-	}
-	notRunningCount := 0
-	for _, pod := range pods.Items {
-
-		if pod.Status.Phase != "Running" {
-			notRunningCount++
-		}
-	}
-	if notRunningCount > 0 {
-		return TestStatus{
-			Status: false,
-			Info:   fmt.Sprintf("%d pods are in not running state\n", notRunningCount),
-			Error:  nil}
-	}
-	return TestStatus{
-		Status: true,
-		Info:   "All pods are running",
-		Error:  nil}
-}
-
-// Check Events on the cluster
-func (kc *K8sClient) CheckEvents() TestStatus {
-	events, err := kc.Client.CoreV1().Events("").List(context.Background(), metav1.ListOptions{})
-	if err != nil {
-		return TestStatus{
-			Status: false,
-			Info:   "",
-			Error:  err}
-	}
-	warningEventsCount := 0
-	errorEventsCount := 0
-
-	for _, event := range events.Items {
-		if event.Type == "Warning" {
-			warningEventsCount++
-		}
-		if event.Type == "Error" {
-			errorEventsCount++
-		}
-	}
-	if warningEventsCount > 0 || errorEventsCount > 0 {
-		return TestStatus{
-			Status: false,
-			Info:   fmt.Sprintf("%d warning events and %d error events found\n", warningEventsCount, errorEventsCount),
-			Error:  nil}
-	}
-
-	return TestStatus{
-		Status: true,
-		Info:   "No failed events found",
-		Error:  nil}
-}
-
 type Alert struct {
 	AlertName string
 	Severity  string
@@ -355,23 +386,23 @@ type origAlert struct {
 	EndsAt      string            `json:"endsAt"`
 }
 
-func (kc *K8sClient) GetAlerts() []Alert {
+// GetAlerts returns the active alerts of alertmanager
+func (kc *K8sClient) GetAlerts(ctx context.Context) ([]Alert, error) {
 	//execute command to get alerts -  kubectl exec -it -n fed-prometheus alertmanager-prometheus-alerts-0 -- sh -c "amtool -o json alert query -a --alertmanager.url http://localhost:9093"
 	alertList := []Alert{}
 
-	command := "sh -c \"amtool -o json alert query -a --alertmanager.url http://localhost:9093\""
-	stdout, stderr, err := kc.ExecuteRemoteCommand("fed-prometheus", "alertmanager-prometheus-alerts-0", "alertmanager", command)
-
+	am := kc.Config.Alertmanager
+	command := fmt.Sprintf("sh -c \"amtool -o json alert query -a --alertmanager.url %s\"", am.URL)
+	stdout, _, err := kc.ExecuteRemoteCommand(ctx, am.Namespace, am.Pod, am.Container, command)
 	if err != nil {
-		fmt.Println(err)
-		fmt.Println(stderr)
+		return nil, fmt.Errorf("querying alertmanager in %s/%s: %w", am.Namespace, am.Pod, err)
 	}
 
 	origAlerts := []origAlert{}
 	// Unmarshal the json output
 	err = json.Unmarshal([]byte(stdout), &origAlerts)
 	if err != nil {
-		fmt.Println(err)
+		return nil, fmt.Errorf("parsing alerts: %w", err)
 	}
 
 	for _, alert := range origAlerts {
@@ -383,39 +414,7 @@ func (kc *K8sClient) GetAlerts() []Alert {
 			Summary:   alert.Annotations["summary"],
 		})
 	}
-	return alertList
-
-}
-
-func (kc *K8sClient) ExecuteRemoteCommand(namespace, pod, container, command string) (string, string, error) {
-	flag.Parse()
-	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
-	if err != nil {
-		panic(err.Error())
-	}
-	buf := &bytes.Buffer{}
-	errBuf := &bytes.Buffer{}
-	request := kc.Client.CoreV1().RESTClient().
-		Post().
-		Namespace(namespace).
-		Resource("pods").
-		Name(pod).
-		SubResource("exec").
-		Param("container", container).
-		VersionedParams(&v1.PodExecOptions{
-			Command: []string{"/bin/sh", "-c", command},
-			Stdin:   false,
-			Stdout:  true,
-			Stderr:  true,
-			TTY:     true,
-		}, scheme.ParameterCodec)
-	exec, _ := remotecommand.NewSPDYExecutor(config, "POST", request.URL())
-	_ = exec.Stream(remotecommand.StreamOptions{
-		Stdout: buf,
-		Stderr: errBuf,
-	})
-
-	return buf.String(), errBuf.String(), nil
+	return alertList, nil
 }
 
 type RedisDbSizeInfo struct {
@@ -423,56 +422,50 @@ type RedisDbSizeInfo struct {
 	Output  string
 }
 
-func (kc *K8sClient) GetRedisDbSize() []RedisDbSizeInfo {
-	redis_namespace := "fed-redis-cluster"
-	redis_container := "redis-node"
+// GetRedisDbSize returns the output of dbsize on the masters, as seen from every redis pod
+func (kc *K8sClient) GetRedisDbSize(ctx context.Context) ([]RedisDbSizeInfo, error) {
+	redis_namespace := kc.Config.Redis.Namespace
+	redis_container := kc.Config.Redis.Container
 
 	returnSize := []RedisDbSizeInfo{}
 
 	//get the list of pods from the redis namespace
-	pods, err := kc.Client.CoreV1().Pods(redis_namespace).List(context.Background(), metav1.ListOptions{})
+	pods, err := kc.Client.CoreV1().Pods(redis_namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	for _, pod := range pods.Items {
 		//execute command to get the redis db size
-		command := fmt.Sprintf("redis-cli --cluster call --cluster-only-masters redis-cluster.%s.svc.cluster.local:6379 dbsize", redis_namespace)
-		stdout, stderr, err := kc.ExecuteRemoteCommand(redis_namespace, pod.Name, redis_container, command)
+		command := fmt.Sprintf("redis-cli --cluster call --cluster-only-masters %s.%s.svc.cluster.local:%d dbsize", kc.Config.Redis.Service, redis_namespace, kc.Config.Redis.Port)
+		stdout, _, err := kc.ExecuteRemoteCommand(ctx, redis_namespace, pod.Name, redis_container, command)
 		if err != nil {
-			fmt.Println(err)
-			fmt.Println(stderr)
+			return returnSize, fmt.Errorf("redis dbsize in pod %s: %w", pod.Name, err)
 		}
 		returnSize = append(returnSize, RedisDbSizeInfo{
 			PodName: pod.Name,
 			Output:  stdout,
 		})
 	}
-	return returnSize
+	return returnSize, nil
 }
-func (kc *K8sClient) FlushRedisData() error {
-	redis_namespace := "fed-redis-cluster"
-	redis_container := "redis-node"
 
-	returnSize := []RedisDbSizeInfo{}
+// FlushRedisData runs flushall on the masters from every redis pod
+func (kc *K8sClient) FlushRedisData(ctx context.Context) error {
+	redis_namespace := kc.Config.Redis.Namespace
+	redis_container := kc.Config.Redis.Container
 
 	//get the list of pods from the redis namespace
-	pods, err := kc.Client.CoreV1().Pods(redis_namespace).List(context.Background(), metav1.ListOptions{})
+	pods, err := kc.Client.CoreV1().Pods(redis_namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return nil
+		return err
 	}
 	for _, pod := range pods.Items {
 		//execute command to flush redis data
-		command := fmt.Sprintf("redis-cli --cluster call --cluster-only-masters redis-cluster.%s.svc.cluster.local:6379 flushall", redis_namespace)
-		stdout, stderr, err := kc.ExecuteRemoteCommand(redis_namespace, pod.Name, redis_container, command)
+		command := fmt.Sprintf("redis-cli --cluster call --cluster-only-masters %s.%s.svc.cluster.local:%d flushall", kc.Config.Redis.Service, redis_namespace, kc.Config.Redis.Port)
+		_, _, err := kc.ExecuteRemoteCommand(ctx, redis_namespace, pod.Name, redis_container, command)
 		if err != nil {
-			fmt.Println(err)
-			fmt.Println(stderr)
-			return err
+			return fmt.Errorf("redis flushall in pod %s: %w", pod.Name, err)
 		}
-		returnSize = append(returnSize, RedisDbSizeInfo{
-			PodName: pod.Name,
-			Output:  stdout,
-		})
 	}
 	return nil
 }
@@ -543,34 +536,39 @@ type missingDetails struct {
 	Memory string
 }
 
-func (kc *K8sClient) GetRedisStatus() RedisStatus {
-	redis_namespace := "fed-redis-cluster"
-	customResourceName := "node-for-redis"
+// GetRedisStatus reads the status of the redis cluster from its custom resource
+func (kc *K8sClient) GetRedisStatus(ctx context.Context) (RedisStatus, error) {
+	redis_namespace := kc.Config.Redis.Namespace
+	customResourceName := kc.Config.Redis.CustomResource
 	customResource, err := kc.DynamicClient.Resource(schema.GroupVersionResource{
-		Group:    "db.ibm.com",
-		Version:  "v1alpha1",
-		Resource: "redisclusters",
-	}).Namespace(redis_namespace).Get(context.Background(), customResourceName, metav1.GetOptions{})
+		Group:    kc.Config.Redis.Group,
+		Version:  kc.Config.Redis.Version,
+		Resource: kc.Config.Redis.Resource,
+	}).Namespace(redis_namespace).Get(ctx, customResourceName, metav1.GetOptions{})
 
 	if err != nil {
-		fmt.Println(err)
-		return RedisStatus{}
+		return RedisStatus{}, err
 	}
 
 	//get the status of the custom resource
 
 	// Assuming the custom resource has a status field
 	status, found, err := unstructured.NestedMap(customResource.Object, "status")
-	if err != nil || !found {
-		log.Fatalf("Error fetching status field: %v", err)
+	if err != nil {
+		return RedisStatus{}, fmt.Errorf("reading status of %s/%s: %w", redis_namespace, customResourceName, err)
+	}
+	if !found {
+		return RedisStatus{}, fmt.Errorf("%s/%s has no status", redis_namespace, customResourceName)
 	}
 
 	var clusterStatus ClusterStatus
 	temp, err := json.Marshal(status)
-
+	if err != nil {
+		return RedisStatus{}, err
+	}
 	err = json.Unmarshal(temp, &clusterStatus)
 	if err != nil {
-		log.Fatalf("Error unmarshalling status field: %v", err)
+		return RedisStatus{}, fmt.Errorf("parsing status of %s/%s: %w", redis_namespace, customResourceName, err)
 	}
 
 	return RedisStatus{
@@ -598,9 +596,11 @@ func (kc *K8sClient) GetRedisStatus() RedisStatus {
 
 			for _, node := range nodeList {
 				var nodeDetails missingDetails
-				pod, err := kc.Client.CoreV1().Pods(redis_namespace).Get(context.Background(), node.PodName, metav1.GetOptions{})
-				if err != nil {
-					fmt.Println(err)
+				pod, err := kc.Client.CoreV1().Pods(redis_namespace).Get(ctx, node.PodName, metav1.GetOptions{})
+				if err != nil || len(pod.Spec.Containers) == 0 {
+					// leave the details of a missing pod empty
+					podDetails[node.PodName] = nodeDetails
+					continue
 				}
 				nodeDetails.Worker = pod.Spec.NodeName
 				nodeDetails.CPU = pod.Spec.Containers[0].Resources.Requests.Cpu().String()
@@ -610,36 +610,27 @@ func (kc *K8sClient) GetRedisStatus() RedisStatus {
 			return podDetails
 
 		}(clusterStatus.Cluster.Nodes),
-	}
-
+	}, nil
 }
 
 // cmd = 'kubectl -n {} exec -it {} -c {} bash -- curl http://127.0.0.1:{}/tenv/eTrace/enable?filter=all\&level=DEBUG_{}'.format(namespace, pod_name, pod_config['container'], pod_config['port'], debug_level)
-func (kc *K8sClient) SetDebugLevel(namespace, pod, container, debugLevel string) bool {
-	port := "9090"
-	//TODO: Port is hardcoded here. It should be fetched from the local config based on the service name
-	//TODO: Prepare the local config file to fetch the port based on the service name
-	command := fmt.Sprintf("curl http://127.0.0.1:%s/tenv/eTrace/enable?filter=all\\&level=%s", port, debugLevel)
-	fmt.Println(command)
-	stdout, stderr, err := kc.ExecuteRemoteCommand(namespace, pod, container, command)
-	if err != nil {
-		fmt.Println(err)
-		fmt.Println(stderr)
-		return false
-	}
-
-	fmt.Println(stdout)
-	return true
+func (kc *K8sClient) SetDebugLevel(ctx context.Context, namespace, pod, container, debugLevel string) error {
+	port := kc.Config.DebugPort(container)
+	// -f makes curl exit non-zero when eTrace answers with an HTTP error
+	command := fmt.Sprintf("curl -sSf http://127.0.0.1:%d/tenv/eTrace/enable?filter=all\\&level=%s", port, debugLevel)
+	_, _, err := kc.ExecuteRemoteCommand(ctx, namespace, pod, container, command)
+	return err
 }
 
-func (kc *K8sClient) GetKargoServiceIP() (string, error) {
-	service, err := kc.Client.CoreV1().Services("fed-paas-helpers").Get(context.Background(), "kargo", metav1.GetOptions{})
+// GetKargoServiceIP returns the load balancer IP of the kargo service
+func (kc *K8sClient) GetKargoServiceIP(ctx context.Context) (string, error) {
+	service, err := kc.Client.CoreV1().Services(kc.Config.Kargo.Namespace).Get(ctx, kc.Config.Kargo.Service, metav1.GetOptions{})
 	if err != nil {
 		return "", err
 	}
 
 	if len(service.Status.LoadBalancer.Ingress) == 0 {
-		return "", fmt.Errorf("no LoadBalancer Ingress found for kargo service")
+		return "", fmt.Errorf("no LoadBalancer Ingress found for %s service", kc.Config.Kargo.Service)
 	}
 
 	return service.Status.LoadBalancer.Ingress[0].IP, nil
@@ -675,19 +666,21 @@ func GetMemoryUsagePercentage(usage, request resource.Quantity) float64 {
 	return float64(usage.Value()) / float64(request.Value()) * 100
 }
 
-func (kc *K8sClient) GetResourceUsageReport() ResourceUsageReport {
+// GetResourceUsageReport compares the usage of every container with its requests,
+// reading the pods from the snapshot
+func (kc *K8sClient) GetResourceUsageReport(ctx context.Context, snapshot *Snapshot) (ResourceUsageReport, error) {
 	report := ResourceUsageReport{}
 	// Get all pods in all namespaces
-	pods, err := kc.Client.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, "")
 	if err != nil {
-		log.Fatalf("Error fetching pods: %v", err)
+		return report, fmt.Errorf("fetching pods: %w", err)
 	}
 
 	// Get metrics for all pods in all namespaces
 
-	podMetricsList, err := kc.MetricsClient.MetricsV1beta1().PodMetricses("").List(context.Background(), metav1.ListOptions{})
+	podMetricsList, err := kc.MetricsClient.MetricsV1beta1().PodMetricses("").List(ctx, metav1.ListOptions{})
 	if err != nil {
-		log.Fatalf("Error fetching pod metrics: %v", err)
+		return report, fmt.Errorf("fetching pod metrics, is metrics-server installed? %w", err)
 	}
 
 	// Create a map of pod metrics by name/namespace for easier lookup
@@ -737,11 +730,9 @@ func (kc *K8sClient) GetResourceUsageReport() ResourceUsageReport {
 				}
 			}
 
-		} else {
-			fmt.Printf("No metrics available for pod: %s/%s\n", pod.Namespace, pod.Name)
 		}
 		report.PodsUsage = append(report.PodsUsage, podusage)
 
 	}
-	return report
+	return report, nil
 }
@@ -0,0 +1,131 @@
+package k8s
+
+import (
+	"bytes"
+
+	"healthctl/pkg/config"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// read is the verb set of every resource that checks only look at
+var read = []string{"get", "list"}
+
+// RBACRules returns the permissions needed by every check and by the details
+// collected for reports. The redis and CephCluster custom resources are taken from
+// the configuration.
+func RBACRules(cfg *config.Config) []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"nodes", "namespaces", "pods", "services", "persistentvolumes", "persistentvolumeclaims", "events"},
+			Verbs:     read,
+		},
+		// kubelet volume stats of the storage suite
+		{APIGroups: []string{""}, Resources: []string{"nodes/proxy"}, Verbs: []string{"get"}},
+		// recent logs of failed pods in the report details
+		{APIGroups: []string{""}, Resources: []string{"pods/log"}, Verbs: []string{"get"}},
+		// smfmonitor, redis and alertmanager are queried from inside their pods
+		{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"create"}},
+		{
+			APIGroups: []string{"apps"},
+			Resources: []string{"deployments", "replicasets", "daemonsets", "statefulsets"},
+			Verbs:     read,
+		},
+		{APIGroups: []string{"discovery.k8s.io"}, Resources: []string{"endpointslices"}, Verbs: read},
+		{APIGroups: []string{"events.k8s.io"}, Resources: []string{"events"}, Verbs: read},
+		{APIGroups: []string{"networking.k8s.io"}, Resources: []string{"ingresses"}, Verbs: read},
+		{APIGroups: []string{"apiextensions.k8s.io"}, Resources: []string{"customresourcedefinitions"}, Verbs: read},
+		{APIGroups: []string{"storage.k8s.io"}, Resources: []string{"storageclasses"}, Verbs: read},
+		{APIGroups: []string{"metrics.k8s.io"}, Resources: []string{"nodes", "pods"}, Verbs: read},
+		{APIGroups: []string{cfg.Redis.Group}, Resources: []string{cfg.Redis.Resource}, Verbs: read},
+		{APIGroups: []string{cfg.Storage.Ceph.Group}, Resources: []string{cfg.Storage.Ceph.Resource}, Verbs: read},
+		{NonResourceURLs: []string{"/version"}, Verbs: []string{"get"}},
+	}
+}
+
+// CronJobOptions describes the CronJob written by RBACManifests, it is left out
+// when Image is empty
+type CronJobOptions struct {
+	Image       string
+	Schedule    string
+	ClusterName string
+	Args        []string
+}
+
+// RBACManifests returns the ServiceAccount, ClusterRole and ClusterRoleBinding that
+// let healthctl run inside the cluster under the given name and namespace, followed
+// by a CronJob running it when cron.Image is set, as one multi-document YAML stream
+func RBACManifests(name, namespace string, cfg *config.Config, cron CronJobOptions) ([]byte, error) {
+	labels := map[string]string{"app.kubernetes.io/name": "healthctl"}
+	objects := []any{
+		&v1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		},
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Rules:      RBACRules(cfg),
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: name},
+			Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: name, Namespace: namespace}},
+		},
+	}
+	if cron.Image != "" {
+		objects = append(objects, cronJob(name, namespace, labels, cron))
+	}
+
+	var out bytes.Buffer
+	for i, obj := range objects {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			out.WriteString("---\n")
+		}
+		out.Write(data)
+	}
+	return out.Bytes(), nil
+}
+
+// cronJob runs healthctl headless with the service account, printing JSON results
+func cronJob(name, namespace string, labels map[string]string, cron CronJobOptions) *batchv1.CronJob {
+	args := []string{"run", "--output", "json"}
+	if cron.ClusterName != "" {
+		args = append(args, "--cluster-name", cron.ClusterName)
+	}
+	args = append(args, cron.Args...)
+	return &batchv1.CronJob{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "CronJob"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Spec: batchv1.CronJobSpec{
+			Schedule:          cron.Schedule,
+			ConcurrencyPolicy: batchv1.ForbidConcurrent,
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec: v1.PodSpec{
+							ServiceAccountName: name,
+							RestartPolicy:      v1.RestartPolicyNever,
+							Containers: []v1.Container{{
+								Name:  "healthctl",
+								Image: cron.Image,
+								Args:  args,
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
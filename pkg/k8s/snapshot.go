@@ -0,0 +1,363 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Snapshot lists every resource type at most once, cluster wide, and serves all
+// later reads from that list. Create one per run so all checks of the run see the
+// same state of the cluster without each of them listing it again.
+//
+// Lists are fetched on first use. A failed list is not remembered, the next reader
+// tries again. The cluster wide lists are shared and must not be modified, the
+// views of a single namespace are copies.
+type Snapshot struct {
+	client kubernetes.Interface
+
+	mu      sync.Mutex
+	entries map[string]*snapshotEntry
+}
+
+type snapshotEntry struct {
+	mu     sync.Mutex
+	loaded bool
+	list   any
+}
+
+// NewSnapshot creates an empty snapshot reading from the given clientset
+func NewSnapshot(client kubernetes.Interface) *Snapshot {
+	return &Snapshot{client: client, entries: map[string]*snapshotEntry{}}
+}
+
+// load returns the cached list of the given resource, listing it on first use.
+// Concurrent readers of the same resource wait for a single list call.
+func (s *Snapshot) load(ctx context.Context, resource string, list func(ctx context.Context) (any, error)) (any, error) {
+	s.mu.Lock()
+	entry, ok := s.entries[resource]
+	if !ok {
+		entry = &snapshotEntry{}
+		s.entries[resource] = entry
+	}
+	s.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.loaded {
+		return entry.list, nil
+	}
+	result, err := list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entry.list = result
+	entry.loaded = true
+	return result, nil
+}
+
+// Nodes returns all nodes of the cluster
+func (s *Snapshot) Nodes(ctx context.Context) (*v1.NodeList, error) {
+	list, err := s.load(ctx, "nodes", func(ctx context.Context) (any, error) {
+		return s.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.(*v1.NodeList), nil
+}
+
+// Pods returns the pods of the namespace, or of all namespaces when namespace is empty
+func (s *Snapshot) Pods(ctx context.Context, namespace string) (*v1.PodList, error) {
+	list, err := s.load(ctx, "pods", func(ctx context.Context) (any, error) {
+		return s.client.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	all := list.(*v1.PodList)
+	if namespace == "" {
+		return all, nil
+	}
+	pods := &v1.PodList{}
+	for _, pod := range all.Items {
+		if pod.Namespace == namespace {
+			pods.Items = append(pods.Items, pod)
+		}
+	}
+	return pods, nil
+}
+
+// PodsWithLabels returns the pods of the namespace matching the label selector
+func (s *Snapshot) PodsWithLabels(ctx context.Context, namespace, selector string) (*v1.PodList, error) {
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.Pods(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	pods := &v1.PodList{}
+	for _, pod := range all.Items {
+		if sel.Matches(labels.Set(pod.Labels)) {
+			pods.Items = append(pods.Items, pod)
+		}
+	}
+	return pods, nil
+}
+
+// Services returns the services of the namespace, or of all namespaces when namespace is empty
+func (s *Snapshot) Services(ctx context.Context, namespace string) (*v1.ServiceList, error) {
+	list, err := s.load(ctx, "services", func(ctx context.Context) (any, error) {
+		return s.client.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	all := list.(*v1.ServiceList)
+	if namespace == "" {
+		return all, nil
+	}
+	services := &v1.ServiceList{}
+	for _, service := range all.Items {
+		if service.Namespace == namespace {
+			services.Items = append(services.Items, service)
+		}
+	}
+	return services, nil
+}
+
+// EndpointSlices returns the endpoint slices of the namespace, or of all namespaces when namespace is empty
+func (s *Snapshot) EndpointSlices(ctx context.Context, namespace string) (*discoveryv1.EndpointSliceList, error) {
+	list, err := s.load(ctx, "endpointslices", func(ctx context.Context) (any, error) {
+		return s.client.DiscoveryV1().EndpointSlices("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	all := list.(*discoveryv1.EndpointSliceList)
+	if namespace == "" {
+		return all, nil
+	}
+	slices := &discoveryv1.EndpointSliceList{}
+	for _, slice := range all.Items {
+		if slice.Namespace == namespace {
+			slices.Items = append(slices.Items, slice)
+		}
+	}
+	return slices, nil
+}
+
+// PersistentVolumes returns all persistent volumes of the cluster
+func (s *Snapshot) PersistentVolumes(ctx context.Context) (*v1.PersistentVolumeList, error) {
+	list, err := s.load(ctx, "persistentvolumes", func(ctx context.Context) (any, error) {
+		return s.client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.(*v1.PersistentVolumeList), nil
+}
+
+// PersistentVolumeClaims returns the claims of the namespace, or of all namespaces when namespace is empty
+func (s *Snapshot) PersistentVolumeClaims(ctx context.Context, namespace string) (*v1.PersistentVolumeClaimList, error) {
+	list, err := s.load(ctx, "persistentvolumeclaims", func(ctx context.Context) (any, error) {
+		return s.client.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	all := list.(*v1.PersistentVolumeClaimList)
+	if namespace == "" {
+		return all, nil
+	}
+	pvcs := &v1.PersistentVolumeClaimList{}
+	for _, pvc := range all.Items {
+		if pvc.Namespace == namespace {
+			pvcs.Items = append(pvcs.Items, pvc)
+		}
+	}
+	return pvcs, nil
+}
+
+// Events returns the events of the namespace, or of all namespaces when namespace is empty
+func (s *Snapshot) Events(ctx context.Context, namespace string) (*v1.EventList, error) {
+	list, err := s.load(ctx, "events", func(ctx context.Context) (any, error) {
+		return s.client.CoreV1().Events("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	all := list.(*v1.EventList)
+	if namespace == "" {
+		return all, nil
+	}
+	events := &v1.EventList{}
+	for _, event := range all.Items {
+		if event.Namespace == namespace {
+			events.Items = append(events.Items, event)
+		}
+	}
+	return events, nil
+}
+
+// EventsV1 returns the events of all namespaces through the events.k8s.io API, which
+// serves the same events as the core API along with their series
+func (s *Snapshot) EventsV1(ctx context.Context) (*eventsv1.EventList, error) {
+	list, err := s.load(ctx, "events.events.k8s.io", func(ctx context.Context) (any, error) {
+		return s.client.EventsV1().Events("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.(*eventsv1.EventList), nil
+}
+
+// Deployments returns the deployments of the namespace, or of all namespaces when namespace is empty
+func (s *Snapshot) Deployments(ctx context.Context, namespace string) (*appsv1.DeploymentList, error) {
+	list, err := s.load(ctx, "deployments", func(ctx context.Context) (any, error) {
+		return s.client.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	all := list.(*appsv1.DeploymentList)
+	if namespace == "" {
+		return all, nil
+	}
+	deployments := &appsv1.DeploymentList{}
+	for _, deployment := range all.Items {
+		if deployment.Namespace == namespace {
+			deployments.Items = append(deployments.Items, deployment)
+		}
+	}
+	return deployments, nil
+}
+
+// ReplicaSets returns the replica sets of the namespace, or of all namespaces when namespace is empty
+func (s *Snapshot) ReplicaSets(ctx context.Context, namespace string) (*appsv1.ReplicaSetList, error) {
+	list, err := s.load(ctx, "replicasets", func(ctx context.Context) (any, error) {
+		return s.client.AppsV1().ReplicaSets("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	all := list.(*appsv1.ReplicaSetList)
+	if namespace == "" {
+		return all, nil
+	}
+	replicasets := &appsv1.ReplicaSetList{}
+	for _, replicaset := range all.Items {
+		if replicaset.Namespace == namespace {
+			replicasets.Items = append(replicasets.Items, replicaset)
+		}
+	}
+	return replicasets, nil
+}
+
+// DaemonSets returns the daemon sets of the namespace, or of all namespaces when namespace is empty
+func (s *Snapshot) DaemonSets(ctx context.Context, namespace string) (*appsv1.DaemonSetList, error) {
+	list, err := s.load(ctx, "daemonsets", func(ctx context.Context) (any, error) {
+		return s.client.AppsV1().DaemonSets("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	all := list.(*appsv1.DaemonSetList)
+	if namespace == "" {
+		return all, nil
+	}
+	daemonsets := &appsv1.DaemonSetList{}
+	for _, daemonset := range all.Items {
+		if daemonset.Namespace == namespace {
+			daemonsets.Items = append(daemonsets.Items, daemonset)
+		}
+	}
+	return daemonsets, nil
+}
+
+// StatefulSets returns the stateful sets of the namespace, or of all namespaces when namespace is empty
+func (s *Snapshot) StatefulSets(ctx context.Context, namespace string) (*appsv1.StatefulSetList, error) {
+	list, err := s.load(ctx, "statefulsets", func(ctx context.Context) (any, error) {
+		return s.client.AppsV1().StatefulSets("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	all := list.(*appsv1.StatefulSetList)
+	if namespace == "" {
+		return all, nil
+	}
+	statefulsets := &appsv1.StatefulSetList{}
+	for _, statefulset := range all.Items {
+		if statefulset.Namespace == namespace {
+			statefulsets.Items = append(statefulsets.Items, statefulset)
+		}
+	}
+	return statefulsets, nil
+}
+
+// Ingresses returns the ingresses of the namespace, or of all namespaces when namespace is empty
+func (s *Snapshot) Ingresses(ctx context.Context, namespace string) (*networkingv1.IngressList, error) {
+	list, err := s.load(ctx, "ingresses", func(ctx context.Context) (any, error) {
+		return s.client.NetworkingV1().Ingresses("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	all := list.(*networkingv1.IngressList)
+	if namespace == "" {
+		return all, nil
+	}
+	ingresses := &networkingv1.IngressList{}
+	for _, ingress := range all.Items {
+		if ingress.Namespace == namespace {
+			ingresses.Items = append(ingresses.Items, ingress)
+		}
+	}
+	return ingresses, nil
+}
+
+// StorageClasses returns all storage classes of the cluster
+func (s *Snapshot) StorageClasses(ctx context.Context) (*storagev1.StorageClassList, error) {
+	list, err := s.load(ctx, "storageclasses", func(ctx context.Context) (any, error) {
+		return s.client.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.(*storagev1.StorageClassList), nil
+}
+
+// ServerVersion returns the version of the API server
+func (s *Snapshot) ServerVersion(ctx context.Context) (*version.Info, error) {
+	info, err := s.load(ctx, "version", func(ctx context.Context) (any, error) {
+		// discovery's ServerVersion takes no context, request /version directly
+		body, err := s.client.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Raw()
+		if err != nil {
+			return nil, err
+		}
+		info := &version.Info{}
+		if err := json.Unmarshal(body, info); err != nil {
+			return nil, fmt.Errorf("decoding server version: %w", err)
+		}
+		return info, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info.(*version.Info), nil
+}
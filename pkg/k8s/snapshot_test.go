@@ -0,0 +1,195 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func testPod(namespace, name string, labels map[string]string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels}}
+}
+
+// countLists counts the list calls per resource made through the clientset
+func countLists(client *fake.Clientset) map[string]*atomic.Int32 {
+	counts := map[string]*atomic.Int32{"pods": {}, "services": {}, "nodes": {}}
+	client.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if count, ok := counts[action.GetResource().Resource]; ok {
+			count.Add(1)
+		}
+		return false, nil, nil
+	})
+	return counts
+}
+
+func TestSnapshotListsOnce(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		testPod("ran", "amf-0", map[string]string{"app": "amf"}),
+		testPod("ran", "smf-0", map[string]string{"app": "smf"}),
+		testPod("core", "upf-0", map[string]string{"app": "upf"}),
+	)
+	counts := countLists(client)
+	snapshot := NewSnapshot(client)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := snapshot.Pods(ctx, ""); err != nil {
+				t.Errorf("Pods() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	ran, err := snapshot.Pods(ctx, "ran")
+	if err != nil {
+		t.Fatalf("Pods(ran) error = %v", err)
+	}
+	if len(ran.Items) != 2 {
+		t.Errorf("Pods(ran) returned %d pods, want 2", len(ran.Items))
+	}
+	amf, err := snapshot.PodsWithLabels(ctx, "ran", "app=amf")
+	if err != nil {
+		t.Fatalf("PodsWithLabels() error = %v", err)
+	}
+	if len(amf.Items) != 1 || amf.Items[0].Name != "amf-0" {
+		t.Errorf("PodsWithLabels(ran, app=amf) = %v, want amf-0", amf.Items)
+	}
+	if _, err := snapshot.Services(ctx, "ran"); err != nil {
+		t.Fatalf("Services() error = %v", err)
+	}
+
+	if n := counts["pods"].Load(); n != 1 {
+		t.Errorf("pods listed %d times, want 1", n)
+	}
+	if n := counts["services"].Load(); n != 1 {
+		t.Errorf("services listed %d times, want 1", n)
+	}
+	if n := counts["nodes"].Load(); n != 0 {
+		t.Errorf("nodes listed %d times, want 0", n)
+	}
+}
+
+func TestSnapshotRetriesFailedList(t *testing.T) {
+	client := fake.NewSimpleClientset(testPod("ran", "amf-0", nil))
+	failures := 1
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if failures > 0 {
+			failures--
+			return true, nil, errors.New("connection refused")
+		}
+		return false, nil, nil
+	})
+	counts := countLists(client)
+	snapshot := NewSnapshot(client)
+
+	if _, err := snapshot.Pods(context.Background(), ""); err == nil {
+		t.Fatal("Pods() error = nil, want the list error")
+	}
+	pods, err := snapshot.Pods(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Pods() error = %v after a failed list", err)
+	}
+	if len(pods.Items) != 1 {
+		t.Errorf("Pods() returned %d pods, want 1", len(pods.Items))
+	}
+	if n := counts["pods"].Load(); n != 2 {
+		t.Errorf("pods listed %d times, want 2", n)
+	}
+}
+
+func TestSnapshotNamespaceViewsAreCopies(t *testing.T) {
+	client := fake.NewSimpleClientset(testPod("ran", "amf-0", nil), testPod("core", "upf-0", nil))
+	snapshot := NewSnapshot(client)
+	ctx := context.Background()
+
+	ran, err := snapshot.Pods(ctx, "ran")
+	if err != nil {
+		t.Fatalf("Pods(ran) error = %v", err)
+	}
+	ran.Items[0].Name = "changed"
+	ran.Items = append(ran.Items, v1.Pod{})
+
+	again, err := snapshot.Pods(ctx, "ran")
+	if err != nil {
+		t.Fatalf("Pods(ran) error = %v", err)
+	}
+	if len(again.Items) != 1 || again.Items[0].Name != "amf-0" {
+		t.Errorf("Pods(ran) = %v after changing an earlier view, want amf-0 only", again.Items)
+	}
+	all, err := snapshot.Pods(ctx, "")
+	if err != nil {
+		t.Fatalf("Pods() error = %v", err)
+	}
+	for _, pod := range all.Items {
+		if pod.Name == "changed" {
+			t.Errorf("changing a namespace view changed the cluster wide list")
+		}
+	}
+}
+
+// versionClient returns a clientset whose API server answers /version
+func versionClient(t *testing.T, calls *atomic.Int32) kubernetes.Interface {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" {
+			http.NotFound(w, r)
+			return
+		}
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"major":"1","minor":"31","gitVersion":"v1.31.1"}`))
+	}))
+	t.Cleanup(server.Close)
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("creating the client: %v", err)
+	}
+	return client
+}
+
+func TestSnapshotServerVersion(t *testing.T) {
+	var calls atomic.Int32
+	snapshot := NewSnapshot(versionClient(t, &calls))
+
+	for i := 0; i < 2; i++ {
+		info, err := snapshot.ServerVersion(context.Background())
+		if err != nil {
+			t.Fatalf("ServerVersion() error = %v", err)
+		}
+		if info.GitVersion != "v1.31.1" {
+			t.Errorf("ServerVersion() = %s, want v1.31.1", info.GitVersion)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("/version requested %d times, want 1", n)
+	}
+}
+
+func TestSnapshotServerVersionCancelled(t *testing.T) {
+	var calls atomic.Int32
+	snapshot := NewSnapshot(versionClient(t, &calls))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := snapshot.ServerVersion(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("ServerVersion() error = %v, want %v", err, context.Canceled)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("/version requested %d times with a cancelled context, want 0", n)
+	}
+}
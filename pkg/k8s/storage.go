@@ -0,0 +1,133 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// CephClusterStatus is the health of a CephCluster as reported by Rook
+type CephClusterStatus struct {
+	Name      string
+	Namespace string
+	// Phase is the Rook phase of the cluster, such as Ready or Progressing
+	Phase string
+	// Health is the Ceph health: HEALTH_OK, HEALTH_WARN or HEALTH_ERR
+	Health string
+	// Messages lists the Ceph health checks that are not OK
+	Messages []string
+}
+
+// cephStatus holds the part of the CephCluster status read by GetCephClusters
+type cephStatus struct {
+	Phase string `json:"phase"`
+	Ceph  struct {
+		Health  string `json:"health"`
+		Details map[string]struct {
+			Message  string `json:"message"`
+			Severity string `json:"severity"`
+		} `json:"details"`
+	} `json:"ceph"`
+}
+
+// GetCephClusters returns the health of the CephClusters of the configured namespace.
+// A NotFound error means the CephCluster resource is not installed.
+func (kc *K8sClient) GetCephClusters(ctx context.Context) ([]CephClusterStatus, error) {
+	ceph := kc.Config.Storage.Ceph
+	list, err := kc.DynamicClient.Resource(schema.GroupVersionResource{
+		Group:    ceph.Group,
+		Version:  ceph.Version,
+		Resource: ceph.Resource,
+	}).Namespace(ceph.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := []CephClusterStatus{}
+	for _, item := range list.Items {
+		raw, err := json.Marshal(item.Object["status"])
+		if err != nil {
+			return nil, err
+		}
+		var status cephStatus
+		if err := json.Unmarshal(raw, &status); err != nil {
+			return nil, err
+		}
+		cluster := CephClusterStatus{
+			Name:      item.GetName(),
+			Namespace: item.GetNamespace(),
+			Phase:     status.Phase,
+			Health:    status.Ceph.Health,
+		}
+		for name, detail := range status.Ceph.Details {
+			cluster.Messages = append(cluster.Messages, name+": "+detail.Message)
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters, nil
+}
+
+// VolumeStats is the usage of a persistent volume claim mounted on a node
+type VolumeStats struct {
+	Namespace     string
+	Claim         string
+	Pod           string
+	CapacityBytes uint64
+	UsedBytes     uint64
+}
+
+// statsSummary holds the part of the kubelet stats summary read by GetVolumeStats
+type statsSummary struct {
+	Pods []struct {
+		PodRef struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"podRef"`
+		Volume []struct {
+			PVCRef *struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"pvcRef"`
+			CapacityBytes *uint64 `json:"capacityBytes"`
+			UsedBytes     *uint64 `json:"usedBytes"`
+		} `json:"volume"`
+	} `json:"pods"`
+}
+
+// GetVolumeStats returns the usage of the persistent volume claims mounted on the
+// node, read from the kubelet stats summary through the API server proxy. Claims
+// the kubelet reports no usage for are left out.
+func (kc *K8sClient) GetVolumeStats(ctx context.Context, node string) ([]VolumeStats, error) {
+	raw, err := kc.Client.CoreV1().RESTClient().Get().
+		Resource("nodes").
+		Name(node).
+		SubResource("proxy").
+		Suffix("stats/summary").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var summary statsSummary
+	if err := json.Unmarshal(raw, &summary); err != nil {
+		return nil, err
+	}
+
+	stats := []VolumeStats{}
+	for _, pod := range summary.Pods {
+		for _, volume := range pod.Volume {
+			if volume.PVCRef == nil || volume.CapacityBytes == nil || volume.UsedBytes == nil {
+				continue
+			}
+			stats = append(stats, VolumeStats{
+				Namespace:     volume.PVCRef.Namespace,
+				Claim:         volume.PVCRef.Name,
+				Pod:           pod.PodRef.Name,
+				CapacityBytes: *volume.CapacityBytes,
+				UsedBytes:     *volume.UsedBytes,
+			})
+		}
+	}
+	return stats, nil
+}
@@ -1,7 +1,90 @@
 package models
 
+import (
+	"fmt"
+	"time"
+)
+
+// Outcome is the result of a single check
+type Outcome string
+
+const (
+	OutcomePass    Outcome = "pass"
+	OutcomeWarn    Outcome = "warn"
+	OutcomeFail    Outcome = "fail"
+	OutcomeError   Outcome = "error"
+	OutcomeSkipped Outcome = "skipped"
+)
+
+// OutcomeFor maps a boolean check result to pass or fail
+func OutcomeFor(passed bool) Outcome {
+	if passed {
+		return OutcomePass
+	}
+	return OutcomeFail
+}
+
+// Severity tells how much a check that did not pass matters
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
 type ResourceCheck struct {
+	// Check is the registered ID of the check that produced the result, as suite/name
+	Check   string
 	Label   string
 	Details string
-	Status  bool
+	Outcome Outcome
+	// Severity defaults to critical for failures and errors when a check leaves it empty
+	Severity Severity
+	// Error is set when the check could not be evaluated, e.g. an API call failed
+	Error error
+	// Duration is the time the check took to run
+	Duration time.Duration
+	// Objects lists the cluster objects behind a check that did not pass
+	Objects []ObjectRef
+}
+
+// Failed reports whether the check failed or could not be evaluated
+func (r ResourceCheck) Failed() bool {
+	return r.Outcome == OutcomeFail || r.Outcome == OutcomeError
+}
+
+// DefaultSeverity returns the severity used for an outcome when a check does not set one
+func DefaultSeverity(outcome Outcome) Severity {
+	switch outcome {
+	case OutcomeFail, OutcomeError:
+		return SeverityCritical
+	case OutcomeWarn:
+		return SeverityWarning
+	}
+	return SeverityInfo
+}
+
+// ObjectRef identifies a cluster object and why it was flagged
+type ObjectRef struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Reason    string
+}
+
+func (o ObjectRef) String() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s/%s", o.Kind, o.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", o.Kind, o.Namespace, o.Name)
+}
+
+// ClusterMetadata describes the cluster a run was executed against
+type ClusterMetadata struct {
+	Context     string
+	Cluster     string
+	MasterNodes int
+	WorkerNodes int
+	APIServer   string
 }
@@ -0,0 +1,67 @@
+package report
+
+import (
+	"context"
+
+	"healthctl/pkg/k8s"
+	"healthctl/pkg/models"
+)
+
+// Limits that keep the developer view readable on badly broken clusters
+const (
+	maxObjectsPerCheck = 20
+	podLogLines        = 50
+)
+
+// Finding is a failed check together with the diagnostics of its affected objects
+type Finding struct {
+	Suite   string
+	Check   models.ResourceCheck
+	Objects []ObjectDetail
+}
+
+// ObjectDetail holds the diagnostics collected for one affected object
+type ObjectDetail struct {
+	models.ObjectRef
+	YAML   string
+	Events []string
+	Logs   string
+	Errors []string
+}
+
+// CollectDetails gathers YAML, events and recent logs for the objects behind every failed check
+func (r *Report) CollectDetails(ctx context.Context, kc *k8s.K8sClient) {
+	r.Findings = nil
+	for _, suite := range r.Suites {
+		for _, check := range suite.Checks {
+			if !check.Failed() && check.Outcome != models.OutcomeWarn {
+				continue
+			}
+			finding := Finding{Suite: suite.Name, Check: check}
+			for i, ref := range check.Objects {
+				if i == maxObjectsPerCheck {
+					break
+				}
+				finding.Objects = append(finding.Objects, collectObject(ctx, kc, ref))
+			}
+			r.Findings = append(r.Findings, finding)
+		}
+	}
+}
+
+func collectObject(ctx context.Context, kc *k8s.K8sClient, ref models.ObjectRef) ObjectDetail {
+	detail := ObjectDetail{ObjectRef: ref}
+	var err error
+	if detail.YAML, err = kc.GetObjectYAML(ctx, ref); err != nil {
+		detail.Errors = append(detail.Errors, "yaml: "+err.Error())
+	}
+	if detail.Events, err = kc.GetObjectEvents(ctx, ref); err != nil {
+		detail.Errors = append(detail.Errors, "events: "+err.Error())
+	}
+	if ref.Kind == "Pod" {
+		if detail.Logs, err = kc.GetPodLogs(ctx, ref.Namespace, ref.Name, podLogLines); err != nil {
+			detail.Errors = append(detail.Errors, "logs: "+err.Error())
+		}
+	}
+	return detail
+}
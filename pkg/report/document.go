@@ -0,0 +1,202 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"healthctl/pkg/models"
+
+	"sigs.k8s.io/yaml"
+)
+
+// SchemaVersion identifies the layout of Document; bump it on incompatible changes
+const SchemaVersion = "healthctl/v1"
+
+// Output formats supported by Write
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Document is the machine readable form of a run, consumed by other tools and dashboards
+type Document struct {
+	APIVersion string    `json:"apiVersion"`
+	Kind       string    `json:"kind"`
+	RunID      string    `json:"runId"`
+	Cluster    string    `json:"cluster"`
+	Context    string    `json:"context"`
+	APIServer  string    `json:"apiServer,omitempty"`
+	StartTime  time.Time `json:"startTime"`
+	EndTime    time.Time `json:"endTime"`
+	Summary    Summary   `json:"summary"`
+	Results    []Result  `json:"results"`
+}
+
+// Summary counts the results of a run
+type Summary struct {
+	Total    int `json:"total"`
+	Passed   int `json:"passed"`
+	Warnings int `json:"warnings"`
+	Failed   int `json:"failed"`
+	Errors   int `json:"errors"`
+	Skipped  int `json:"skipped"`
+}
+
+// Result is a single check of a suite. Status is one of pass, warn, fail, error
+// or skipped; severity is one of info, warning or critical.
+type Result struct {
+	Suite           string            `json:"suite"`
+	Check           string            `json:"check"`
+	Label           string            `json:"label"`
+	Status          string            `json:"status"`
+	Severity        string            `json:"severity"`
+	Message         string            `json:"message"`
+	Error           string            `json:"error,omitempty"`
+	DurationMs      int64             `json:"durationMs"`
+	AffectedObjects []ObjectReference `json:"affectedObjects,omitempty"`
+}
+
+// ObjectReference identifies an object behind a failed check
+type ObjectReference struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// FleetDocument is the machine readable form of a run across several clusters
+type FleetDocument struct {
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	RunID      string      `json:"runId"`
+	StartTime  time.Time   `json:"startTime"`
+	EndTime    time.Time   `json:"endTime"`
+	Summary    Summary     `json:"summary"`
+	Matrix     []MatrixRow `json:"matrix"`
+	Clusters   []Document  `json:"clusters"`
+}
+
+// MatrixRow is the verdict of every suite on one cluster; suites that were not
+// run on the cluster are left out
+type MatrixRow struct {
+	Context string            `json:"context"`
+	Cluster string            `json:"cluster"`
+	Verdict string            `json:"verdict"`
+	Suites  map[string]string `json:"suites"`
+}
+
+// Document converts the report to its versioned machine readable form
+func (r *Report) Document() Document {
+	doc := Document{
+		APIVersion: SchemaVersion,
+		Kind:       "HealthReport",
+		RunID:      r.RunID,
+		Cluster:    r.Cluster.Cluster,
+		Context:    r.Cluster.Context,
+		APIServer:  r.Cluster.APIServer,
+		StartTime:  r.StartTime,
+		EndTime:    r.EndTime,
+		Summary:    newSummary(r.Total(), r.Count),
+		Results:    []Result{},
+	}
+	for _, suite := range r.Suites {
+		for _, check := range suite.Checks {
+			doc.Results = append(doc.Results, newResult(suite.Name, check))
+		}
+	}
+	return doc
+}
+
+// Document converts the fleet run to its versioned machine readable form
+func (f *Fleet) Document() FleetDocument {
+	doc := FleetDocument{
+		APIVersion: SchemaVersion,
+		Kind:       "FleetHealthReport",
+		RunID:      f.RunID,
+		StartTime:  f.StartTime,
+		EndTime:    f.EndTime,
+		Summary:    newSummary(f.Total(), f.Count),
+		Matrix:     []MatrixRow{},
+		Clusters:   []Document{},
+	}
+	for _, r := range f.Reports {
+		row := MatrixRow{
+			Context: r.Cluster.Context,
+			Cluster: r.Cluster.Cluster,
+			Verdict: string(r.Verdict()),
+			Suites:  map[string]string{},
+		}
+		for _, suite := range r.Suites {
+			row.Suites[suite.Name] = string(suite.Verdict())
+		}
+		doc.Matrix = append(doc.Matrix, row)
+		doc.Clusters = append(doc.Clusters, r.Document())
+	}
+	return doc
+}
+
+func newSummary(total int, count func(outcomes ...models.Outcome) int) Summary {
+	return Summary{
+		Total:    total,
+		Passed:   count(models.OutcomePass),
+		Warnings: count(models.OutcomeWarn),
+		Failed:   count(models.OutcomeFail),
+		Errors:   count(models.OutcomeError),
+		Skipped:  count(models.OutcomeSkipped),
+	}
+}
+
+func newResult(suite string, check models.ResourceCheck) Result {
+	result := Result{
+		Suite:      suite,
+		Check:      check.Check,
+		Label:      check.Label,
+		Status:     string(check.Outcome),
+		Severity:   string(check.Severity),
+		Message:    check.Details,
+		DurationMs: check.Duration.Milliseconds(),
+	}
+	if check.Error != nil {
+		result.Error = check.Error.Error()
+	}
+	for _, object := range check.Objects {
+		result.AffectedObjects = append(result.AffectedObjects, ObjectReference{
+			Kind:      object.Kind,
+			Namespace: object.Namespace,
+			Name:      object.Name,
+			Reason:    object.Reason,
+		})
+	}
+	return result
+}
+
+// Write encodes the report document in the given format
+func Write(w io.Writer, r *Report, format string) error {
+	return encode(w, r.Document(), format)
+}
+
+// WriteFleet encodes the fleet document in the given format
+func WriteFleet(w io.Writer, f *Fleet, format string) error {
+	return encode(w, f.Document(), format)
+}
+
+func encode(w io.Writer, doc any, format string) error {
+	var out []byte
+	var err error
+	switch format {
+	case FormatJSON:
+		out, err = json.MarshalIndent(doc, "", "  ")
+		out = append(out, '\n')
+	case FormatYAML:
+		out, err = yaml.Marshal(doc)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
@@ -0,0 +1,110 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthctl/pkg/models"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var testStart = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// testReport returns a report of the given context with one check of every outcome
+func testReport(context string) *Report {
+	r := &Report{
+		RunID:     "8c5e1a52-0c1e-4a39-9b0e-3f0d0f6f1a11",
+		StartTime: testStart,
+		Cluster:   models.ClusterMetadata{Context: context, Cluster: context + "-cluster", MasterNodes: 3, WorkerNodes: 5, APIServer: "https://" + context + ":6443"},
+	}
+	r.Add("k8s", []models.ResourceCheck{
+		{Check: "k8s/nodes", Label: "Nodes", Details: "All 8 nodes are ready", Outcome: models.OutcomePass, Severity: models.SeverityInfo, Duration: 120 * time.Millisecond},
+		{
+			Check:    "k8s/pods",
+			Label:    "Pods fed-smf/smf",
+			Details:  "1 of 3 pods unhealthy: CrashLoopBackOff (1)",
+			Outcome:  models.OutcomeFail,
+			Severity: models.SeverityCritical,
+			Duration: 340 * time.Millisecond,
+			Objects:  []models.ObjectRef{{Kind: "Pod", Namespace: "fed-smf", Name: "smf-1", Reason: "container smf: CrashLoopBackOff, 7 restarts"}},
+		},
+		{Check: "k8s/events", Label: "Events", Details: "12 warning events in the last hour", Outcome: models.OutcomeWarn, Severity: models.SeverityWarning, Duration: 80 * time.Millisecond},
+	})
+	r.Add("storage", []models.ResourceCheck{
+		{Check: "storage/ceph", Label: "Ceph", Details: "Error fetching CephClusters", Outcome: models.OutcomeError, Severity: models.SeverityCritical, Error: errors.New(`cephclusters.ceph.rook.io is forbidden`), Duration: 2 * time.Second},
+		{Check: "storage/volume-usage", Label: "volume-usage", Details: "Check storage/volume-usage was cancelled", Outcome: models.OutcomeSkipped, Severity: models.SeverityInfo},
+	})
+	// Add stamps the end time with the clock
+	r.EndTime = testStart.Add(4 * time.Second)
+	return r
+}
+
+// testFleet returns a fleet run over a cluster with every outcome and a healthy one
+func testFleet() *Fleet {
+	f := &Fleet{RunID: "8c5e1a52-0c1e-4a39-9b0e-3f0d0f6f1a11", StartTime: testStart}
+	f.Add(testReport("prod-east"))
+	healthy := &Report{
+		RunID:     f.RunID,
+		StartTime: testStart,
+		Cluster:   models.ClusterMetadata{Context: "prod-west", Cluster: "prod-west-cluster"},
+	}
+	healthy.Add("k8s", []models.ResourceCheck{{Check: "k8s/nodes", Label: "Nodes", Details: "All 4 nodes are ready", Outcome: models.OutcomePass, Severity: models.SeverityInfo}})
+	healthy.EndTime = testStart.Add(time.Second)
+	f.Add(healthy)
+	f.EndTime = testStart.Add(5 * time.Second)
+	return f
+}
+
+// checkGolden compares got with the golden file, rewriting it when -update is set
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file, got:\n%s", name, got)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := Write(&out, testReport("prod-east"), FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "report.json", out.Bytes())
+}
+
+func TestWriteFleetJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteFleet(&out, testFleet(), FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "fleet.json", out.Bytes())
+}
+
+func TestWriteYAML(t *testing.T) {
+	var out bytes.Buffer
+	if err := Write(&out, testReport("prod-east"), FormatYAML); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "report.yaml", out.Bytes())
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, testReport("prod-east"), "xml"); err == nil {
+		t.Error("Write(xml) error = nil, want an unsupported format error")
+	}
+}
@@ -0,0 +1,111 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"healthctl/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// Fleet holds the reports of one run across several clusters, one report per
+// kubeconfig context. It feeds the cluster x suite matrix.
+type Fleet struct {
+	RunID     string
+	StartTime time.Time
+	EndTime   time.Time
+	Reports   []*Report
+}
+
+// NewFleet starts an empty fleet run
+func NewFleet() *Fleet {
+	now := time.Now()
+	return &Fleet{
+		RunID:     uuid.NewString(),
+		StartTime: now,
+		EndTime:   now,
+	}
+}
+
+// NewReport starts the report of one cluster of the fleet run
+func (f *Fleet) NewReport(cluster models.ClusterMetadata) *Report {
+	r := New(cluster)
+	r.RunID = f.RunID
+	return r
+}
+
+// Add appends the finished report of a cluster to the fleet
+func (f *Fleet) Add(r *Report) {
+	f.Reports = append(f.Reports, r)
+	f.EndTime = time.Now()
+}
+
+// SuiteNames returns the names of the suites run on any cluster, in the order they were first seen
+func (f *Fleet) SuiteNames() []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, r := range f.Reports {
+		for _, suite := range r.Suites {
+			if !seen[suite.Name] {
+				seen[suite.Name] = true
+				names = append(names, suite.Name)
+			}
+		}
+	}
+	return names
+}
+
+// Suite returns the result of the named suite, or nil when it was not run
+func (r *Report) Suite(name string) *SuiteResult {
+	for i := range r.Suites {
+		if r.Suites[i].Name == name {
+			return &r.Suites[i]
+		}
+	}
+	return nil
+}
+
+// Verdict returns the worst verdict of all clusters
+func (f *Fleet) Verdict() Verdict {
+	verdict := VerdictUnknown
+	for _, r := range f.Reports {
+		if v := r.Verdict(); verdictRank[v] > verdictRank[verdict] {
+			verdict = v
+		}
+	}
+	return verdict
+}
+
+// Total returns the number of checks across all clusters
+func (f *Fleet) Total() int {
+	total := 0
+	for _, r := range f.Reports {
+		total += r.Total()
+	}
+	return total
+}
+
+// Count returns the number of checks with one of the given outcomes across all clusters
+func (f *Fleet) Count(outcomes ...models.Outcome) int {
+	count := 0
+	for _, r := range f.Reports {
+		count += r.Count(outcomes...)
+	}
+	return count
+}
+
+// Failed returns the number of checks that failed or could not be evaluated across all clusters
+func (f *Fleet) Failed() int {
+	return f.Count(models.OutcomeFail, models.OutcomeError)
+}
+
+// Errors returns the number of checks that could not be evaluated across all clusters
+func (f *Fleet) Errors() int {
+	return f.Count(models.OutcomeError)
+}
+
+// FileName returns a default file name for the fleet report with the given extension
+func (f *Fleet) FileName(ext string) string {
+	return fmt.Sprintf("healthctl-fleet-%s.%s", f.StartTime.Format("20060102-150405"), ext)
+}
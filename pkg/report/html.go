@@ -0,0 +1,190 @@
+package report
+
+import (
+	"html/template"
+	"io"
+	"os"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`{{define "style"}}<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+h3 { margin: 0.8em 0 0.3em; }
+.timestamp { color: #666; margin-top: 0; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 6px 10px; border-bottom: 1px solid #ddd; vertical-align: top; }
+th { background: #f4f4f4; }
+.meta { width: auto; margin-bottom: 1.5em; }
+.meta th { background: none; font-weight: 600; }
+.badge { display: inline-block; padding: 2px 8px; border-radius: 4px; color: #fff; font-size: 0.85em; font-weight: 600; text-transform: uppercase; }
+.pass, .green { background: #2e7d32; }
+.fail, .red { background: #c62828; }
+.error { background: #7f0000; }
+.warn, .amber { background: #ef8f00; }
+.skipped, .grey { background: #757575; }
+details { margin-bottom: 1em; border: 1px solid #ddd; border-radius: 4px; }
+details details { margin: 0.5em 1em; }
+summary { cursor: pointer; padding: 8px 12px; background: #fafafa; font-weight: 600; }
+details table { margin: 0; }
+pre { background: #f7f7f7; padding: 8px; overflow-x: auto; font-size: 0.85em; margin: 0 1em 1em; }
+.object { padding: 0 0 0.5em; }
+.object p { margin: 0.3em 1em; }
+.message { color: #c62828; }
+input[name=view] { display: none; }
+.tabs label { display: inline-block; padding: 8px 16px; border: 1px solid #ddd; border-bottom: none; border-radius: 4px 4px 0 0; cursor: pointer; background: #f4f4f4; }
+.view { display: none; border-top: 1px solid #ddd; padding-top: 1em; }
+#tab-summary:checked ~ #view-summary, #tab-detail:checked ~ #view-detail { display: block; }
+#tab-summary:checked ~ .tabs label[for=tab-summary], #tab-detail:checked ~ .tabs label[for=tab-detail] { background: #fff; font-weight: 600; }
+.matrix td, .matrix th { text-align: center; }
+.matrix td:first-child, .matrix th:first-child { text-align: left; }
+</style>{{end}}
+
+{{define "checks"}}{{range .Suites}}
+<details{{if .Failed}} open{{end}}>
+<summary><span class="badge {{.Verdict}}">{{.Verdict}}</span> {{.Name}} &mdash; {{.Passed}}/{{len .Checks}} passed</summary>
+<table>
+<tr><th>No.</th><th>Check</th><th>Details</th><th>Severity</th><th>Time</th><th>Result</th></tr>
+{{range $index, $check := .Checks}}<tr><td>{{inc $index}}</td><td>{{$check.Label}}</td><td>{{$check.Details}}</td><td>{{$check.Severity}}</td><td>{{$check.Duration}}</td><td><span class="badge {{$check.Outcome}}">{{$check.Outcome}}</span></td></tr>
+{{end}}</table>
+</details>
+{{end}}{{end}}
+
+{{define "findings"}}{{if .Findings}}<h2>Checks that did not pass</h2>{{end}}
+{{range .Findings}}
+<details open>
+<summary><span class="badge {{.Check.Outcome}}">{{.Check.Outcome}}</span> {{.Suite}} / {{.Check.Label}} &mdash; {{.Check.Details}}</summary>
+{{with .Check.Error}}<p class="object message">&nbsp;&nbsp;{{.}}</p>{{end}}
+{{if not .Objects}}<p class="object">&nbsp;&nbsp;No affected objects were reported by this check.</p>{{end}}
+{{range .Objects}}
+<details>
+<summary>{{.ObjectRef}}{{if .Reason}} &mdash; {{.Reason}}{{end}}</summary>
+<div class="object">
+{{range .Errors}}<p class="message">{{.}}</p>{{end}}
+{{if .Events}}<h3>&nbsp;&nbsp;Events</h3><pre>{{range .Events}}{{.}}
+{{end}}</pre>{{end}}
+{{if .Logs}}<h3>&nbsp;&nbsp;Recent logs</h3><pre>{{.Logs}}</pre>{{end}}
+{{if .YAML}}<h3>&nbsp;&nbsp;YAML</h3><pre>{{.YAML}}</pre>{{end}}
+</div>
+</details>
+{{end}}
+</details>
+{{end}}{{end}}
+
+{{define "report"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>healthctl report - {{.Cluster.Cluster}}</title>
+{{template "style"}}
+</head>
+<body>
+<h1>healthctl report</h1>
+<p class="timestamp">Run {{.RunID}} &mdash; started {{.StartTime.Format "2006-01-02 15:04:05 MST"}}, finished {{.EndTime.Format "2006-01-02 15:04:05 MST"}}</p>
+<table class="meta">
+<tr><th>Context</th><td>{{.Cluster.Context}}</td></tr>
+<tr><th>Cluster</th><td>{{.Cluster.Cluster}}</td></tr>
+<tr><th>Nodes</th><td>Master: {{.Cluster.MasterNodes}}, Worker: {{.Cluster.WorkerNodes}}</td></tr>
+<tr><th>apiserver</th><td>{{.Cluster.APIServer}}</td></tr>
+<tr><th>Status</th><td><span class="badge {{.Verdict}}">{{.Verdict}}</span> {{if .Failed}}{{.Failed}} of {{.Total}} checks failed{{else}}{{.Total}} checks passed{{end}}</td></tr>
+</table>
+
+<input type="radio" name="view" id="tab-summary" checked>
+<input type="radio" name="view" id="tab-detail">
+<div class="tabs"><label for="tab-summary">Management summary</label><label for="tab-detail">Developer details</label></div>
+
+<div class="view" id="view-summary">
+<table>
+<tr><th>Suite</th><th>Status</th><th>Passed</th><th>Warnings</th><th>Failed</th></tr>
+{{range .Suites}}<tr><td>{{.Name}}</td><td><span class="badge {{.Verdict}}">{{.Verdict}}</span></td><td>{{.Passed}}</td><td>{{.Count "warn"}}</td><td>{{.Failed}}</td></tr>
+{{end}}</table>
+</div>
+
+<div class="view" id="view-detail">
+{{template "checks" .}}
+{{template "findings" .}}
+</div>
+</body>
+</html>
+{{end}}
+
+{{define "fleet"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>healthctl fleet report</title>
+{{template "style"}}
+</head>
+<body>
+<h1>healthctl fleet report</h1>
+<p class="timestamp">Run {{.RunID}} &mdash; started {{.StartTime.Format "2006-01-02 15:04:05 MST"}}, finished {{.EndTime.Format "2006-01-02 15:04:05 MST"}}</p>
+<table class="meta">
+<tr><th>Clusters</th><td>{{len .Reports}}</td></tr>
+<tr><th>Status</th><td><span class="badge {{.Verdict}}">{{.Verdict}}</span> {{if .Failed}}{{.Failed}} of {{.Total}} checks failed{{else}}{{.Total}} checks passed{{end}}</td></tr>
+</table>
+
+<input type="radio" name="view" id="tab-summary" checked>
+<input type="radio" name="view" id="tab-detail">
+<div class="tabs"><label for="tab-summary">Management summary</label><label for="tab-detail">Developer details</label></div>
+
+<div class="view" id="view-summary">
+{{$suites := .SuiteNames}}
+<table class="matrix">
+<tr><th>Context</th><th>Cluster</th><th>Status</th>{{range $suites}}<th>{{.}}</th>{{end}}</tr>
+{{range .Reports}}{{$report := .}}<tr><td>{{.Cluster.Context}}</td><td>{{.Cluster.Cluster}}</td><td><span class="badge {{.Verdict}}">{{.Verdict}}</span></td>{{range $suites}}<td>{{with $report.Suite .}}<span class="badge {{.Verdict}}">{{.Passed}}/{{len .Checks}}</span>{{else}}&mdash;{{end}}</td>{{end}}</tr>
+{{end}}</table>
+</div>
+
+<div class="view" id="view-detail">
+{{range .Reports}}
+<details{{if .Failed}} open{{end}}>
+<summary><span class="badge {{.Verdict}}">{{.Verdict}}</span> {{.Cluster.Context}} &mdash; cluster {{.Cluster.Cluster}}{{with .Cluster.APIServer}}, {{.}}{{end}}</summary>
+<div class="object">
+{{template "checks" .}}
+{{template "findings" .}}
+</div>
+</details>
+{{end}}
+</div>
+</body>
+</html>
+{{end}}`))
+
+// WriteHTML renders the report as a single self-contained HTML page with a
+// management summary view and a developer details view
+func WriteHTML(w io.Writer, r *Report) error {
+	return htmlTemplate.ExecuteTemplate(w, "report", r)
+}
+
+// WriteHTMLFile renders the report to the given path
+func WriteHTMLFile(path string, r *Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteHTML(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteFleetHTML renders the fleet report as a single self-contained HTML page with
+// the cluster x suite matrix as summary and the results of every cluster as details
+func WriteFleetHTML(w io.Writer, f *Fleet) error {
+	return htmlTemplate.ExecuteTemplate(w, "fleet", f)
+}
+
+// WriteFleetHTMLFile renders the fleet report to the given path
+func WriteFleetHTMLFile(path string, f *Fleet) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteFleetHTML(file, f); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
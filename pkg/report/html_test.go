@@ -0,0 +1,69 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"healthctl/pkg/models"
+)
+
+func TestWriteHTML(t *testing.T) {
+	r := testReport("prod-east")
+	for _, suite := range r.Suites {
+		for _, check := range suite.Checks {
+			if check.Outcome != models.OutcomePass {
+				r.Findings = append(r.Findings, Finding{Suite: suite.Name, Check: check})
+			}
+		}
+	}
+	r.Findings[0].Objects = []ObjectDetail{{
+		ObjectRef: r.Findings[0].Check.Objects[0],
+		Events:    []string{"Warning BackOff: Back-off restarting failed container <smf>"},
+		Logs:      "panic: dial tcp 10.0.0.1:6379: connection refused",
+	}}
+
+	var out bytes.Buffer
+	if err := WriteHTML(&out, r); err != nil {
+		t.Fatal(err)
+	}
+	html := out.String()
+
+	for _, want := range []string{
+		`<span class="badge red">red</span> 2 of 5 checks failed`,
+		`<span class="badge warn">warn</span>`,
+		`<span class="badge error">error</span>`,
+		`<span class="badge skipped">skipped</span>`,
+		`<td>k8s</td><td><span class="badge red">red</span></td><td>1</td><td>1</td><td>1</td>`,
+		`<td>storage</td><td><span class="badge red">red</span></td><td>0</td><td>0</td><td>1</td>`,
+		`<p class="object message">&nbsp;&nbsp;cephclusters.ceph.rook.io is forbidden</p>`,
+		`Back-off restarting failed container &lt;smf&gt;`,
+		`No affected objects were reported by this check.`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML report does not contain %s", want)
+		}
+	}
+	if n := strings.Count(html, "<summary><span class=\"badge "); n != len(r.Suites)+len(r.Findings) {
+		t.Errorf("HTML report has %d sections, want one per suite and finding (%d)", n, len(r.Suites)+len(r.Findings))
+	}
+}
+
+func TestWriteFleetHTML(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteFleetHTML(&out, testFleet()); err != nil {
+		t.Fatal(err)
+	}
+	html := out.String()
+
+	for _, want := range []string{
+		`<tr><th>Context</th><th>Cluster</th><th>Status</th><th>k8s</th><th>storage</th></tr>`,
+		`<td>prod-east</td><td>prod-east-cluster</td><td><span class="badge red">red</span></td><td><span class="badge red">1/3</span></td><td><span class="badge red">0/2</span></td>`,
+		// the storage suite was not run on prod-west
+		`<td>prod-west</td><td>prod-west-cluster</td><td><span class="badge green">green</span></td><td><span class="badge green">1/1</span></td><td>&mdash;</td>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("fleet HTML report does not contain %s", want)
+		}
+	}
+}
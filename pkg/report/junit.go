@@ -0,0 +1,186 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"healthctl/pkg/models"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Hostname   string          `xml:"hostname,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	TestCases  []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name       string          `xml:"name,attr"`
+	Classname  string          `xml:"classname,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Skipped    *junitMessage   `xml:"skipped,omitempty"`
+	Failure    *junitMessage   `xml:"failure,omitempty"`
+	Error      *junitMessage   `xml:"error,omitempty"`
+	SystemOut  string          `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit encodes the report as JUnit XML, one testsuite per suite and one testcase per check
+func WriteJUnit(w io.Writer, r *Report) error {
+	suites := junitTestSuites{
+		Name:     "healthctl",
+		Tests:    r.Total(),
+		Failures: r.Count(models.OutcomeFail),
+		Errors:   r.Count(models.OutcomeError),
+		Time:     fmt.Sprintf("%.3f", r.EndTime.Sub(r.StartTime).Seconds()),
+		Suites:   newJUnitTestSuites(r, ""),
+	}
+	return encodeJUnit(w, suites)
+}
+
+// WriteFleetJUnit encodes the fleet run as JUnit XML, one testsuite per cluster and
+// suite, named context/suite
+func WriteFleetJUnit(w io.Writer, f *Fleet) error {
+	suites := junitTestSuites{
+		Name:     "healthctl",
+		Tests:    f.Total(),
+		Failures: f.Count(models.OutcomeFail),
+		Errors:   f.Count(models.OutcomeError),
+		Time:     fmt.Sprintf("%.3f", f.EndTime.Sub(f.StartTime).Seconds()),
+	}
+	for _, r := range f.Reports {
+		suites.Suites = append(suites.Suites, newJUnitTestSuites(r, r.Cluster.Context+"/")...)
+	}
+	return encodeJUnit(w, suites)
+}
+
+// newJUnitTestSuites converts the suites of the report, prefix is prepended to the
+// suite names to keep them apart when several clusters share a file
+func newJUnitTestSuites(r *Report, prefix string) []junitTestSuite {
+	suites := []junitTestSuite{}
+	for _, suite := range r.Suites {
+		ts := junitTestSuite{
+			Name:      prefix + suite.Name,
+			Tests:     len(suite.Checks),
+			Failures:  suite.Count(models.OutcomeFail),
+			Errors:    suite.Count(models.OutcomeError),
+			Skipped:   suite.Count(models.OutcomeSkipped),
+			Timestamp: r.StartTime.Format("2006-01-02T15:04:05"),
+			Hostname:  r.Cluster.Cluster,
+			Properties: []junitProperty{
+				{Name: "runId", Value: r.RunID},
+				{Name: "context", Value: r.Cluster.Context},
+				{Name: "cluster", Value: r.Cluster.Cluster},
+				{Name: "apiserver", Value: r.Cluster.APIServer},
+			},
+		}
+		for _, check := range suite.Checks {
+			ts.TestCases = append(ts.TestCases, newJUnitTestCase(prefix+suite.Name, check))
+		}
+		suites = append(suites, ts)
+	}
+	return suites
+}
+
+func encodeJUnit(w io.Writer, suites junitTestSuites) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func newJUnitTestCase(suite string, check models.ResourceCheck) junitTestCase {
+	tc := junitTestCase{
+		Name:      check.Label,
+		Classname: "healthctl." + strings.ReplaceAll(suite, "/", "."),
+		Time:      fmt.Sprintf("%.3f", check.Duration.Seconds()),
+	}
+	// the name is the label of the result, the property the check that produced it
+	if check.Check != "" {
+		tc.Properties = []junitProperty{{Name: "check", Value: check.Check}}
+	}
+	lines := []string{check.Details}
+	for _, object := range check.Objects {
+		if object.Reason != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", object, object.Reason))
+		} else {
+			lines = append(lines, object.String())
+		}
+	}
+	switch check.Outcome {
+	case models.OutcomeError:
+		text := check.Details
+		if check.Error != nil {
+			text = check.Error.Error()
+		}
+		tc.Error = &junitMessage{Message: check.Details, Type: "error", Text: text}
+	case models.OutcomeFail:
+		tc.Failure = &junitMessage{Message: check.Details, Type: string(check.Severity), Text: strings.Join(lines, "\n")}
+	case models.OutcomeSkipped:
+		tc.Skipped = &junitMessage{Message: check.Details}
+	case models.OutcomeWarn:
+		tc.SystemOut = "WARNING: " + strings.Join(lines, "\n")
+	}
+	return tc
+}
+
+// WriteJUnitFile writes the JUnit XML report to the given path
+func WriteJUnitFile(path string, r *Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteJUnit(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteFleetJUnitFile writes the JUnit XML report of the fleet run to the given path
+func WriteFleetJUnitFile(path string, f *Fleet) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteFleetJUnit(file, f); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
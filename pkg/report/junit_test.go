@@ -0,0 +1,117 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func decodeJUnit(t *testing.T, data []byte) junitTestSuites {
+	t.Helper()
+	var suites junitTestSuites
+	if err := xml.Unmarshal(data, &suites); err != nil {
+		t.Fatalf("decoding the JUnit XML: %v\n%s", err, data)
+	}
+	return suites
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteJUnit(&out, testReport("prod-east")); err != nil {
+		t.Fatal(err)
+	}
+	suites := decodeJUnit(t, out.Bytes())
+
+	if suites.Tests != 5 || suites.Failures != 1 || suites.Errors != 1 {
+		t.Errorf("testsuites tests %d, failures %d, errors %d, want 5, 1, 1", suites.Tests, suites.Failures, suites.Errors)
+	}
+	want := []struct {
+		name                             string
+		tests, failures, errors, skipped int
+	}{
+		{name: "k8s", tests: 3, failures: 1},
+		{name: "storage", tests: 2, errors: 1, skipped: 1},
+	}
+	if len(suites.Suites) != len(want) {
+		t.Fatalf("got %d testsuites, want %d", len(suites.Suites), len(want))
+	}
+	for i, w := range want {
+		got := suites.Suites[i]
+		if got.Name != w.name || got.Tests != w.tests || got.Failures != w.failures || got.Errors != w.errors || got.Skipped != w.skipped {
+			t.Errorf("testsuite %d = %s tests %d, failures %d, errors %d, skipped %d, want %s %d, %d, %d, %d",
+				i, got.Name, got.Tests, got.Failures, got.Errors, got.Skipped, w.name, w.tests, w.failures, w.errors, w.skipped)
+		}
+	}
+}
+
+func TestJUnitTestCaseOutcomes(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteJUnit(&out, testReport("prod-east")); err != nil {
+		t.Fatal(err)
+	}
+	suites := decodeJUnit(t, out.Bytes())
+	cases := map[string]junitTestCase{}
+	for _, suite := range suites.Suites {
+		for _, tc := range suite.TestCases {
+			cases[tc.Name] = tc
+		}
+	}
+
+	tests := []struct {
+		name    string
+		failure bool
+		error   bool
+		skipped bool
+		output  string
+	}{
+		{name: "Nodes"},
+		{name: "Pods fed-smf/smf", failure: true},
+		{name: "Events", output: "WARNING: 12 warning events in the last hour"},
+		{name: "Ceph", error: true},
+		{name: "volume-usage", skipped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc, ok := cases[tt.name]
+			if !ok {
+				t.Fatalf("no testcase %q", tt.name)
+			}
+			if (tc.Failure != nil) != tt.failure || (tc.Error != nil) != tt.error || (tc.Skipped != nil) != tt.skipped {
+				t.Errorf("testcase failure %v, error %v, skipped %v, want %v, %v, %v", tc.Failure != nil, tc.Error != nil, tc.Skipped != nil, tt.failure, tt.error, tt.skipped)
+			}
+			if tc.SystemOut != tt.output {
+				t.Errorf("system-out = %q, want %q", tc.SystemOut, tt.output)
+			}
+		})
+	}
+
+	if failure := cases["Pods fed-smf/smf"].Failure; failure != nil && !strings.Contains(failure.Text, "Pod/fed-smf/smf-1: container smf: CrashLoopBackOff") {
+		t.Errorf("failure text %q does not list the affected pod", failure.Text)
+	}
+	if e := cases["Ceph"].Error; e != nil && e.Text != "cephclusters.ceph.rook.io is forbidden" {
+		t.Errorf("error text = %q, want the check error", e.Text)
+	}
+}
+
+func TestWriteFleetJUnit(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteFleetJUnit(&out, testFleet()); err != nil {
+		t.Fatal(err)
+	}
+	suites := decodeJUnit(t, out.Bytes())
+
+	if suites.Tests != 6 || suites.Failures != 1 || suites.Errors != 1 {
+		t.Errorf("testsuites tests %d, failures %d, errors %d, want 6, 1, 1", suites.Tests, suites.Failures, suites.Errors)
+	}
+	names := []string{}
+	for _, suite := range suites.Suites {
+		names = append(names, suite.Name)
+	}
+	if got, want := strings.Join(names, ","), "prod-east/k8s,prod-east/storage,prod-west/k8s"; got != want {
+		t.Errorf("testsuite names = %s, want %s", got, want)
+	}
+	if classname := suites.Suites[0].TestCases[0].Classname; classname != "healthctl.prod-east.k8s" {
+		t.Errorf("classname = %s, want healthctl.prod-east.k8s", classname)
+	}
+}
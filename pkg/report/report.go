@@ -0,0 +1,156 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"healthctl/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// Report holds the results of every suite of a run together with the cluster it ran against.
+// Suites feed the management summary, Findings feed the developer view.
+type Report struct {
+	RunID     string
+	StartTime time.Time
+	EndTime   time.Time
+	Cluster   models.ClusterMetadata
+	Suites    []SuiteResult
+	Findings  []Finding
+}
+
+// Verdict is the red/amber/green rollup shown in the management summary
+type Verdict string
+
+const (
+	VerdictGreen   Verdict = "green"
+	VerdictAmber   Verdict = "amber"
+	VerdictRed     Verdict = "red"
+	VerdictUnknown Verdict = "grey"
+)
+
+var verdictRank = map[Verdict]int{VerdictUnknown: 0, VerdictGreen: 1, VerdictAmber: 2, VerdictRed: 3}
+
+// SuiteResult is the outcome of a single suite
+type SuiteResult struct {
+	Name   string
+	Checks []models.ResourceCheck
+}
+
+// New starts an empty report for the given cluster
+func New(cluster models.ClusterMetadata) *Report {
+	now := time.Now()
+	return &Report{
+		RunID:     uuid.NewString(),
+		StartTime: now,
+		EndTime:   now,
+		Cluster:   cluster,
+	}
+}
+
+// Add appends the checks of a suite to the report
+func (r *Report) Add(suite string, checks []models.ResourceCheck) {
+	r.Suites = append(r.Suites, SuiteResult{Name: suite, Checks: checks})
+	r.EndTime = time.Now()
+}
+
+// Count returns the number of checks in the suite with one of the given outcomes
+func (s SuiteResult) Count(outcomes ...models.Outcome) int {
+	count := 0
+	for _, check := range s.Checks {
+		for _, outcome := range outcomes {
+			if check.Outcome == outcome {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+// Passed returns the number of passed checks in the suite
+func (s SuiteResult) Passed() int {
+	return s.Count(models.OutcomePass)
+}
+
+// Failed returns the number of checks in the suite that failed or could not be evaluated
+func (s SuiteResult) Failed() int {
+	return s.Count(models.OutcomeFail, models.OutcomeError)
+}
+
+// Errors returns the number of checks in the suite that could not be evaluated
+func (s SuiteResult) Errors() int {
+	return s.Count(models.OutcomeError)
+}
+
+// Verdict rolls the suite up: red when a critical check failed, amber when any
+// other check failed or warned, green when everything else passed
+func (s SuiteResult) Verdict() Verdict {
+	verdict := VerdictUnknown
+	for _, check := range s.Checks {
+		v := VerdictGreen
+		switch {
+		case check.Failed() && check.Severity == models.SeverityCritical:
+			v = VerdictRed
+		case check.Failed() || check.Outcome == models.OutcomeWarn:
+			v = VerdictAmber
+		case check.Outcome == models.OutcomeSkipped:
+			v = VerdictUnknown
+		}
+		if verdictRank[v] > verdictRank[verdict] {
+			verdict = v
+		}
+	}
+	return verdict
+}
+
+// Verdict returns the worst verdict of all suites
+func (r *Report) Verdict() Verdict {
+	verdict := VerdictUnknown
+	for _, suite := range r.Suites {
+		if v := suite.Verdict(); verdictRank[v] > verdictRank[verdict] {
+			verdict = v
+		}
+	}
+	return verdict
+}
+
+// Total returns the number of checks across all suites
+func (r *Report) Total() int {
+	total := 0
+	for _, suite := range r.Suites {
+		total += len(suite.Checks)
+	}
+	return total
+}
+
+// Count returns the number of checks with one of the given outcomes across all suites
+func (r *Report) Count(outcomes ...models.Outcome) int {
+	count := 0
+	for _, suite := range r.Suites {
+		count += suite.Count(outcomes...)
+	}
+	return count
+}
+
+// Failed returns the number of checks that failed or could not be evaluated across all suites
+func (r *Report) Failed() int {
+	return r.Count(models.OutcomeFail, models.OutcomeError)
+}
+
+// Errors returns the number of checks that could not be evaluated across all suites
+func (r *Report) Errors() int {
+	return r.Count(models.OutcomeError)
+}
+
+// FileName returns a default file name for the report with the given extension
+func (r *Report) FileName(ext string) string {
+	cluster := r.Cluster.Cluster
+	if cluster == "" {
+		cluster = "cluster"
+	}
+	cluster = strings.NewReplacer("/", "-", ":", "-", " ", "-").Replace(cluster)
+	return fmt.Sprintf("healthctl-%s-%s.%s", cluster, r.StartTime.Format("20060102-150405"), ext)
+}
@@ -0,0 +1,95 @@
+package testsuite
+
+import (
+	"context"
+
+	"healthctl/pkg/config"
+	"healthctl/pkg/k8s"
+	"healthctl/pkg/models"
+)
+
+// Clients bundles the clients available to a check during a run
+type Clients struct {
+	*k8s.K8sClient
+	// Snapshot serves the cluster wide lists shared by all checks of the run
+	Snapshot *k8s.Snapshot
+}
+
+// NewClients prepares the clients for a new run with an empty snapshot
+func NewClients(kc *k8s.K8sClient) *Clients {
+	return &Clients{K8sClient: kc, Snapshot: k8s.NewSnapshot(kc.Client)}
+}
+
+// Check is a single health check belonging to a suite
+type Check interface {
+	// Name identifies the check within its suite
+	Name() string
+	// Suite is the name of the suite the check belongs to
+	Suite() string
+	Description() string
+	Tags() []string
+	// Run executes the check and returns one or more results; it should give up
+	// once ctx is done
+	Run(ctx context.Context, clients *Clients) []models.ResourceCheck
+}
+
+// RunFunc is the body of a check created with NewCheck
+type RunFunc func(ctx context.Context, clients *Clients) []models.ResourceCheck
+
+type funcCheck struct {
+	name        string
+	suite       string
+	description string
+	tags        []string
+	run         RunFunc
+}
+
+// NewCheck creates a Check from a function
+func NewCheck(suite, name, description string, tags []string, run RunFunc) Check {
+	return &funcCheck{
+		name:        name,
+		suite:       suite,
+		description: description,
+		tags:        tags,
+		run:         run,
+	}
+}
+
+func (c *funcCheck) Name() string        { return c.name }
+func (c *funcCheck) Suite() string       { return c.suite }
+func (c *funcCheck) Description() string { return c.description }
+func (c *funcCheck) Tags() []string      { return c.tags }
+
+func (c *funcCheck) Run(ctx context.Context, clients *Clients) []models.ResourceCheck {
+	return c.run(ctx, clients)
+}
+
+// snapshotCheck adapts a check function returning one result to a RunFunc
+func snapshotCheck(fn func(ctx context.Context, snapshot *k8s.Snapshot) models.ResourceCheck) RunFunc {
+	return func(ctx context.Context, clients *Clients) []models.ResourceCheck {
+		return []models.ResourceCheck{fn(ctx, clients.Snapshot)}
+	}
+}
+
+// snapshotChecks adapts a check function returning several results to a RunFunc
+func snapshotChecks(fn func(ctx context.Context, snapshot *k8s.Snapshot) []models.ResourceCheck) RunFunc {
+	return func(ctx context.Context, clients *Clients) []models.ResourceCheck {
+		return fn(ctx, clients.Snapshot)
+	}
+}
+
+// configCheck adapts a check function that locates its components through the
+// configuration and returns one result to a RunFunc
+func configCheck(fn func(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck) RunFunc {
+	return func(ctx context.Context, clients *Clients) []models.ResourceCheck {
+		return []models.ResourceCheck{fn(ctx, clients.Snapshot, clients.Config)}
+	}
+}
+
+// configChecks adapts a check function that locates its components through the
+// configuration and returns several results to a RunFunc
+func configChecks(fn func(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) []models.ResourceCheck) RunFunc {
+	return func(ctx context.Context, clients *Clients) []models.ResourceCheck {
+		return fn(ctx, clients.Snapshot, clients.Config)
+	}
+}
@@ -3,94 +3,303 @@ package testsuite
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
+	"healthctl/pkg/config"
+	"healthctl/pkg/k8s"
 	"healthctl/pkg/models"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/version"
 )
 
-func CheckK8s(clientset *kubernetes.Clientset) []models.ResourceCheck {
+func init() {
+	Register(
+		NewCheck(SuiteK8s, "nodes", "All nodes are Ready and schedulable, without pressure, skew or stale heartbeats", []string{"nodes"}, configCheck(checkNodes)),
+		NewCheck(SuiteK8s, "pods", "No pod is crashing, OOM killed, unable to pull its image, unschedulable or not ready", []string{"workloads"}, snapshotChecks(checkPods)),
+		NewCheck(SuiteK8s, "persistent-volumes", "All persistent volumes are Bound", []string{"storage"}, snapshotCheck(checkPVs)),
+		NewCheck(SuiteK8s, "persistent-volume-claims", "Persistent volume claims exist", []string{"storage"}, snapshotCheck(checkPVCs)),
+		NewCheck(SuiteK8s, "services", "Services exist", []string{"network"}, snapshotCheck(checkServices)),
+		NewCheck(SuiteK8s, "service-endpoints", "Services have ready endpoints for their pods and LoadBalancers an external IP", []string{"network"}, snapshotCheck(checkServiceEndpoints)),
+		NewCheck(SuiteK8s, "deployments", "All deployments are rolled out with their replicas ready and available", []string{"workloads"}, snapshotCheck(checkDeployments)),
+		NewCheck(SuiteK8s, "replicasets", "Current replica sets have their replicas ready", []string{"workloads"}, snapshotCheck(checkReplicaSets)),
+		NewCheck(SuiteK8s, "events", "No recent warning events with a failing reason", []string{"events"}, configCheck(checkEvents)),
+		NewCheck(SuiteK8s, "ingresses", "Ingresses exist", []string{"network"}, snapshotCheck(checkIngresses)),
+		NewCheck(SuiteK8s, "daemonsets", "All daemon sets are scheduled, ready and rolled out on their nodes", []string{"workloads"}, snapshotCheck(checkDaemonSets)),
+		NewCheck(SuiteK8s, "statefulsets", "All stateful sets are rolled out with their replicas ready", []string{"workloads"}, snapshotCheck(checkStatefulSets)),
+	)
+}
 
-	checks := []models.ResourceCheck{
-		checkNodes(clientset),
-		checkPods(clientset),
-		checkPVs(clientset),
-		checkPVCs(clientset),
-		checkServices(clientset),
-		checkDeployments(clientset),
-		checkReplicaSets(clientset),
-		checkEvents(clientset),
-		checkIngresses(clientset),
-		checkDaemonSets(clientset),
-		checkStatefulSets(clientset),
+// Check functions
+func checkNodes(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
+	nodes, err := snapshot.Nodes(ctx)
+	if err != nil {
+		return models.ResourceCheck{Label: "Nodes", Details: "Error fetching nodes", Outcome: models.OutcomeError, Error: err}
+	}
+	server, err := snapshot.ServerVersion(ctx)
+	if err != nil {
+		return models.ResourceCheck{Label: "Nodes", Details: "Error fetching the API server version", Outcome: models.OutcomeError, Error: err}
+	}
+	serverVersion, err := version.ParseGeneric(server.GitVersion)
+	if err != nil {
+		return models.ResourceCheck{Label: "Nodes", Details: "Error parsing the API server version", Outcome: models.OutcomeError, Error: err}
+	}
+
+	outcome := models.OutcomePass
+	degraded := []models.ObjectRef{}
+	summary := []string{}
+	total := map[string]int{}
+	affected := map[string]int{}
+	for _, node := range nodes.Items {
+		role := nodeRole(&node)
+		total[role]++
+		problems, failed := nodeProblems(&node, serverVersion, cfg.Nodes)
+		if len(problems) == 0 {
+			continue
+		}
+		affected[role]++
+		if failed {
+			outcome = models.OutcomeFail
+		} else if outcome == models.OutcomePass {
+			outcome = models.OutcomeWarn
+		}
+		degraded = append(degraded, models.ObjectRef{Kind: "Node", Name: node.Name, Reason: strings.Join(problems, ", ")})
+		summary = append(summary, fmt.Sprintf("%s on %s", strings.Join(problems, ", "), node.Name))
 	}
 
-	return checks
+	if len(degraded) == 0 {
+		return models.ResourceCheck{Label: "Nodes", Details: fmt.Sprintf("All %d nodes healthy (%d masters, %d workers)", len(nodes.Items), total["master"], total["worker"]), Outcome: models.OutcomePass}
+	}
+	counts := []string{}
+	for _, role := range []string{"master", "worker"} {
+		if affected[role] > 0 {
+			counts = append(counts, fmt.Sprintf("%d of %d %ss", affected[role], total[role], role))
+		}
+	}
+	if len(summary) > maxNodeSummary {
+		summary = append(summary[:maxNodeSummary], fmt.Sprintf("and %d more nodes", len(summary)-maxNodeSummary))
+	}
+	return models.ResourceCheck{
+		Label:   "Nodes",
+		Details: fmt.Sprintf("%s degraded: %s", strings.Join(counts, " and "), strings.Join(summary, "; ")),
+		Outcome: outcome,
+		Objects: degraded,
+	}
 }
 
-// Check functions
-func checkNodes(clientset *kubernetes.Clientset) models.ResourceCheck {
-	nodes, err := clientset.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
-	if err != nil {
-		return models.ResourceCheck{Label: "Nodes", Details: "Error fetching nodes", Status: false}
+// maxNodeSummary is the number of degraded nodes named in the details, the rest are counted
+const maxNodeSummary = 3
+
+// nodePressure maps the node conditions that must be false to the problem they report
+var nodePressure = []struct {
+	condition v1.NodeConditionType
+	problem   string
+}{
+	{v1.NodeMemoryPressure, "memory pressure"},
+	{v1.NodeDiskPressure, "disk pressure"},
+	{v1.NodePIDPressure, "PID pressure"},
+	{v1.NodeNetworkUnavailable, "network unavailable"},
+}
+
+// nodeProblems lists what is wrong with the node. failed is true when one of the
+// problems makes the node unusable, the others, such as cordoning, are warnings.
+func nodeProblems(node *v1.Node, server *version.Version, expect config.Nodes) (problems []string, failed bool) {
+	ready := false
+	var heartbeat time.Time
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			ready = condition.Status == v1.ConditionTrue
+			heartbeat = condition.LastHeartbeatTime.Time
+		}
+	}
+	if !ready {
+		problems = append(problems, "not ready")
+		failed = true
+	}
+	for _, pressure := range nodePressure {
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == pressure.condition && condition.Status == v1.ConditionTrue {
+				problems = append(problems, pressure.problem)
+				failed = true
+			}
+		}
+	}
+	if age := time.Since(heartbeat); !heartbeat.IsZero() && age > expect.HeartbeatTimeout.Duration {
+		problems = append(problems, fmt.Sprintf("no heartbeat for %s", age.Round(time.Minute)))
+		failed = true
 	}
 
-	nodeNames := make([]string, len(nodes.Items))
-	for i, node := range nodes.Items {
-		nodeNames[i] = node.Name
+	if node.Spec.Unschedulable {
+		problems = append(problems, "cordoned")
+	}
+	for _, taint := range node.Spec.Taints {
+		if !expectedTaint(taint, expect.ExpectedTaints) {
+			problems = append(problems, fmt.Sprintf("taint %s", taint.ToString()))
+		}
 	}
+	if skew := kubeletSkew(node.Status.NodeInfo.KubeletVersion, server, expect.MaxKubeletSkew); skew != "" {
+		problems = append(problems, skew)
+	}
+	return problems, failed
+}
+
+// expectedTaint reports whether the taint is listed as key or key:effect, or is one
+// the node controller sets for a condition or cordoning, which are reported already
+func expectedTaint(taint v1.Taint, expected []string) bool {
+	if strings.HasPrefix(taint.Key, "node.kubernetes.io/") {
+		return true
+	}
+	for _, entry := range expected {
+		if entry == taint.Key || entry == taint.Key+":"+string(taint.Effect) {
+			return true
+		}
+	}
+	return false
+}
+
+// kubeletSkew describes a kubelet version outside of the supported skew: of another
+// major version, newer than the API server or more than maxSkew minor versions behind it
+func kubeletSkew(kubelet string, server *version.Version, maxSkew int) string {
+	kubeletVersion, err := version.ParseGeneric(kubelet)
+	if err != nil {
+		return fmt.Sprintf("unknown kubelet version %q", kubelet)
+	}
+	if kubeletVersion.Major() != server.Major() {
+		return fmt.Sprintf("kubelet %s has another major version than the API server %s", kubelet, server)
+	}
+	if kubeletVersion.Minor() > server.Minor() {
+		return fmt.Sprintf("kubelet %s is newer than the API server %s", kubelet, server)
+	}
+	if behind := int(server.Minor() - kubeletVersion.Minor()); behind > maxSkew {
+		return fmt.Sprintf("kubelet %s is %d minor versions behind the API server %s", kubelet, behind, server)
+	}
+	return ""
+}
 
-	return models.ResourceCheck{Label: "Nodes", Details: fmt.Sprint("Number of nodes : ", len(nodes.Items)), Status: true}
+// nodeRole returns master for control plane nodes and worker for the others
+func nodeRole(node *v1.Node) string {
+	if _, ok := node.Labels["node-role.kubernetes.io/control-plane"]; ok {
+		return "master"
+	}
+	return "worker"
 }
 
-func checkPods(clientset *kubernetes.Clientset) models.ResourceCheck {
-	pods, err := clientset.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{})
+// checkPods classifies every unhealthy pod by root cause and returns one result per
+// namespace and owning workload with unhealthy pods
+func checkPods(ctx context.Context, snapshot *k8s.Snapshot) []models.ResourceCheck {
+	pods, err := snapshot.Pods(ctx, "")
 	if err != nil {
-		return models.ResourceCheck{Label: "Pods", Details: "Error fetching pods", Status: false}
+		return []models.ResourceCheck{{Label: "Pods", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}}
+	}
+	replicaSets, err := snapshot.ReplicaSets(ctx, "")
+	if err != nil {
+		return []models.ResourceCheck{{Label: "Pods", Details: "Error fetching replica sets", Outcome: models.OutcomeError, Error: err}}
+	}
+	replicaSetsByName := map[string]*appsv1.ReplicaSet{}
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		replicaSetsByName[rs.Namespace+"/"+rs.Name] = rs
+	}
+
+	type workload struct {
+		total  int
+		failed bool
+		causes map[podCause]int
+		pods   []models.ObjectRef
+	}
+	workloads := map[string]*workload{}
+	unhealthy := 0
+	now := time.Now()
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		key := pod.Namespace + "/" + podWorkload(pod, replicaSetsByName)
+		w, ok := workloads[key]
+		if !ok {
+			w = &workload{causes: map[podCause]int{}}
+			workloads[key] = w
+		}
+		w.total++
+		diagnosis, ok := diagnosePod(pod, now)
+		if !ok {
+			continue
+		}
+		unhealthy++
+		w.failed = w.failed || !diagnosis.Warning
+		w.causes[diagnosis.Cause]++
+		w.pods = append(w.pods, models.ObjectRef{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name, Reason: diagnosis.Reason})
 	}
 
-	totalPods := len(pods.Items)
-	healthyPods := 0
+	if unhealthy == 0 {
+		return []models.ResourceCheck{{
+			Label:   "Pods",
+			Details: fmt.Sprintf("Total: %d, Healthy: %d. Status: All pods are healthy.", len(pods.Items), len(pods.Items)),
+			Outcome: models.OutcomePass,
+		}}
+	}
 
-	for _, pod := range pods.Items {
-		if pod.Status.Phase == "Running" || pod.Status.Phase == "Succeeded" {
-			healthyPods++
+	keys := []string{}
+	for key, w := range workloads {
+		if len(w.pods) > 0 {
+			keys = append(keys, key)
 		}
 	}
-	details := fmt.Sprintf("Total: %d, Healthy: %d. Status: %s", totalPods, healthyPods,
-		getPodsHealthMessage(totalPods, healthyPods))
-	return models.ResourceCheck{Label: "Pods", Details: details, Status: healthyPods == totalPods}
+	sort.Strings(keys)
+	checks := []models.ResourceCheck{}
+	for _, key := range keys {
+		w := workloads[key]
+		causes := []string{}
+		for cause, count := range w.causes {
+			causes = append(causes, fmt.Sprintf("%s (%d)", cause, count))
+		}
+		sort.Strings(causes)
+		outcome := models.OutcomeFail
+		if !w.failed {
+			outcome = models.OutcomeWarn
+		}
+		checks = append(checks, models.ResourceCheck{
+			Label:   "Pods " + key,
+			Details: fmt.Sprintf("%d of %d pods unhealthy: %s", len(w.pods), w.total, strings.Join(causes, ", ")),
+			Outcome: outcome,
+			Objects: w.pods,
+		})
+	}
+	return checks
 }
 
-func getPodsHealthMessage(total int, healthy int) string {
-	if total == 0 {
-		return "No pods are available."
+// podFailureReason returns the most specific reason a pod is not healthy
+func podFailureReason(pod *v1.Pod) string {
+	if diagnosis, ok := diagnosePod(pod, time.Now()); ok {
+		return diagnosis.Reason
 	}
-	if healthy == total {
-		return "All pods are healthy."
+	if pod.Status.Reason != "" {
+		return pod.Status.Reason
 	}
-	return fmt.Sprintf("%d out of %d pods are healthy.", healthy, total)
+	return string(pod.Status.Phase)
 }
 
-func checkPVs(clientset *kubernetes.Clientset) models.ResourceCheck {
-	pvs, err := clientset.CoreV1().PersistentVolumes().List(context.Background(), metav1.ListOptions{})
+func checkPVs(ctx context.Context, snapshot *k8s.Snapshot) models.ResourceCheck {
+	pvs, err := snapshot.PersistentVolumes(ctx)
 	if err != nil {
-		return models.ResourceCheck{Label: "Persistent Volumes", Details: "Error fetching persistent volumes", Status: false}
+		return models.ResourceCheck{Label: "Persistent Volumes", Details: "Error fetching persistent volumes", Outcome: models.OutcomeError, Error: err}
 	}
 
 	count := len(pvs.Items)
 	details := fmt.Sprintf("Total: %d", count)
 	if count == 0 {
 		details = "No persistent volumes are available."
-		return models.ResourceCheck{Label: "Persistent Volumes", Details: details, Status: false}
+		return models.ResourceCheck{Label: "Persistent Volumes", Details: details, Outcome: models.OutcomeFail}
 	}
 
 	allBound := true
+	unbound := []models.ObjectRef{}
 	for _, pv := range pvs.Items {
 		if pv.Status.Phase != "Bound" {
 			allBound = false
-			break
+			unbound = append(unbound, models.ObjectRef{Kind: "PersistentVolume", Name: pv.Name, Reason: string(pv.Status.Phase)})
 		}
 	}
 
@@ -100,191 +309,211 @@ func checkPVs(clientset *kubernetes.Clientset) models.ResourceCheck {
 		details = "Some persistent volumes are not bound."
 	}
 
-	return models.ResourceCheck{Label: "Persistent Volumes", Details: details, Status: allBound}
+	return models.ResourceCheck{Label: "Persistent Volumes", Details: details, Outcome: models.OutcomeFor(allBound), Objects: unbound}
 }
 
-func checkPVCs(clientset *kubernetes.Clientset) models.ResourceCheck {
-	pvcs, err := clientset.CoreV1().PersistentVolumeClaims("").List(context.Background(), metav1.ListOptions{})
+func checkPVCs(ctx context.Context, snapshot *k8s.Snapshot) models.ResourceCheck {
+	pvcs, err := snapshot.PersistentVolumeClaims(ctx, "")
 	if err != nil {
-		return models.ResourceCheck{Label: "Persistent Volume Claims", Details: "Error fetching persistent volume claims", Status: false}
+		return models.ResourceCheck{Label: "Persistent Volume Claims", Details: "Error fetching persistent volume claims", Outcome: models.OutcomeError, Error: err}
 	}
 
 	count := len(pvcs.Items)
 	details := fmt.Sprintf("Count of PVC: %d", count)
 	if count == 0 {
 		details = "No persistent volume claims are available."
-		return models.ResourceCheck{Label: "Persistent Volume Claims", Details: details, Status: false}
+		return models.ResourceCheck{Label: "Persistent Volume Claims", Details: details, Outcome: models.OutcomeFail}
 	}
 
-	return models.ResourceCheck{Label: "Persistent Volume Claims", Details: details, Status: true}
+	return models.ResourceCheck{Label: "Persistent Volume Claims", Details: details, Outcome: models.OutcomePass}
 }
 
-func checkServices(clientset *kubernetes.Clientset) models.ResourceCheck {
-	services, err := clientset.CoreV1().Services("").List(context.Background(), metav1.ListOptions{})
+func checkServices(ctx context.Context, snapshot *k8s.Snapshot) models.ResourceCheck {
+	services, err := snapshot.Services(ctx, "")
 	if err != nil {
-		return models.ResourceCheck{Label: "Services", Details: "Error fetching services", Status: false}
+		return models.ResourceCheck{Label: "Services", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 	count := len(services.Items)
 	details := fmt.Sprintf("Count of services: %d", count)
 	if count == 0 {
 		details = "No services are available."
 	}
-	return models.ResourceCheck{Label: "Services", Details: details, Status: count > 0}
+	return models.ResourceCheck{Label: "Services", Details: details, Outcome: models.OutcomeFor(count > 0)}
 }
 
-func checkDeployments(clientset *kubernetes.Clientset) models.ResourceCheck {
-	deployments, err := clientset.AppsV1().Deployments("").List(context.Background(), metav1.ListOptions{})
+// checkServiceEndpoints reports services with a selector and no ready endpoints, or
+// fewer ready endpoints than running pods, and LoadBalancer services without an external IP
+func checkServiceEndpoints(ctx context.Context, snapshot *k8s.Snapshot) models.ResourceCheck {
+	services, err := snapshot.Services(ctx, "")
 	if err != nil {
-		return models.ResourceCheck{Label: "Deployments", Details: "Error fetching deployments", Status: false}
+		return models.ResourceCheck{Label: "Service Endpoints", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
-
-	if len(deployments.Items) == 0 {
-		return models.ResourceCheck{Label: "Deployments", Details: "No deployments are available.", Status: false}
+	endpointSlices, err := snapshot.EndpointSlices(ctx, "")
+	if err != nil {
+		return models.ResourceCheck{Label: "Service Endpoints", Details: "Error fetching endpoint slices", Outcome: models.OutcomeError, Error: err}
+	}
+	pods, err := snapshot.Pods(ctx, "")
+	if err != nil {
+		return models.ResourceCheck{Label: "Service Endpoints", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
-	allHealthy := true
-	for _, deploy := range deployments.Items {
-		if *deploy.Spec.Replicas != deploy.Status.ReadyReplicas {
-			allHealthy = false
-			break
+	outcome := models.OutcomePass
+	flagged := []models.ObjectRef{}
+	summary := []string{}
+	for i := range services.Items {
+		service := &services.Items[i]
+		problem, failed := serviceProblem(service, endpointSlices.Items, pods.Items)
+		if problem == "" {
+			continue
 		}
+		if failed {
+			outcome = models.OutcomeFail
+		} else if outcome == models.OutcomePass {
+			outcome = models.OutcomeWarn
+		}
+		flagged = append(flagged, models.ObjectRef{Kind: "Service", Namespace: service.Namespace, Name: service.Name, Reason: problem})
+		summary = append(summary, fmt.Sprintf("%s/%s (%s)", service.Namespace, service.Name, problem))
 	}
-	var details string
-	if allHealthy {
-		details = "All deployments are healthy."
-	} else {
-		details = "Some deployments are not healthy."
+	if len(flagged) == 0 {
+		return models.ResourceCheck{Label: "Service Endpoints", Details: fmt.Sprintf("All %d services have ready endpoints.", len(services.Items)), Outcome: models.OutcomePass}
+	}
+	if len(summary) > maxWorkloadSummary {
+		summary = append(summary[:maxWorkloadSummary], fmt.Sprintf("and %d more", len(summary)-maxWorkloadSummary))
+	}
+	return models.ResourceCheck{
+		Label:   "Service Endpoints",
+		Details: fmt.Sprintf("%d of %d services degraded: %s", len(flagged), len(services.Items), strings.Join(summary, "; ")),
+		Outcome: outcome,
+		Objects: flagged,
 	}
-
-	return models.ResourceCheck{Label: "Deployments", Details: details, Status: allHealthy}
 }
 
-func checkReplicaSets(clientset *kubernetes.Clientset) models.ResourceCheck {
-	replicasets, err := clientset.AppsV1().ReplicaSets("").List(context.Background(), metav1.ListOptions{})
+func checkDeployments(ctx context.Context, snapshot *k8s.Snapshot) models.ResourceCheck {
+	deployments, err := snapshot.Deployments(ctx, "")
 	if err != nil {
-		return models.ResourceCheck{Label: "Replica Sets", Details: "Error fetching replica sets", Status: false}
+		return models.ResourceCheck{Label: "Deployments", Details: "Error fetching deployments", Outcome: models.OutcomeError, Error: err}
 	}
-
-	count := len(replicasets.Items)
-	details := fmt.Sprintf("Total: %d", count)
-	if count == 0 {
-		details = "No replica sets are available."
-		return models.ResourceCheck{Label: "Replica Sets", Details: details, Status: false}
+	rollouts := []rollout{}
+	for i := range deployments.Items {
+		rollouts = append(rollouts, deploymentRollout(&deployments.Items[i]))
 	}
+	return rolloutResult("Deployments", "deployments", rollouts)
+}
 
-	allHealthy := true
-	for _, rs := range replicasets.Items {
-		if *rs.Spec.Replicas != rs.Status.ReadyReplicas {
-			allHealthy = false
-			break
-		}
+func checkReplicaSets(ctx context.Context, snapshot *k8s.Snapshot) models.ResourceCheck {
+	replicasets, err := snapshot.ReplicaSets(ctx, "")
+	if err != nil {
+		return models.ResourceCheck{Label: "Replica Sets", Details: "Error fetching replica sets", Outcome: models.OutcomeError, Error: err}
 	}
-
-	if allHealthy {
-		details = "All replica sets are healthy."
-	} else {
-		details = "Some replica sets are not healthy."
+	deployments, err := snapshot.Deployments(ctx, "")
+	if err != nil {
+		return models.ResourceCheck{Label: "Replica Sets", Details: "Error fetching deployments", Outcome: models.OutcomeError, Error: err}
+	}
+	deploymentsByName := map[string]*appsv1.Deployment{}
+	for i := range deployments.Items {
+		deploy := &deployments.Items[i]
+		deploymentsByName[deploy.Namespace+"/"+deploy.Name] = deploy
 	}
 
-	return models.ResourceCheck{Label: "Replica Sets", Details: details, Status: allHealthy}
+	rollouts := []rollout{}
+	for i := range replicasets.Items {
+		rs := &replicasets.Items[i]
+		if supersededReplicaSet(rs, deploymentsByName) {
+			continue
+		}
+		rollouts = append(rollouts, replicaSetRollout(rs))
+	}
+	return rolloutResult("Replica Sets", "replica sets", rollouts)
 }
 
-func checkEvents(clientset *kubernetes.Clientset) models.ResourceCheck {
-	events, err := clientset.CoreV1().Events("").List(context.Background(), metav1.ListOptions{})
+// checkEvents fails on recent warning events with one of the configured reasons and
+// warns on other recent warnings, listing the noisiest objects
+func checkEvents(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
+	lookback := cfg.Events.Lookback.Duration
+	warnings, partial, err := recentWarnings(ctx, snapshot, time.Now().Add(-lookback))
 	if err != nil {
-		return models.ResourceCheck{Label: "Events", Details: "Error fetching events", Status: false}
+		return models.ResourceCheck{Label: "Events", Details: "Error fetching events", Outcome: models.OutcomeError, Error: err}
+	}
+	// without events.k8s.io the check only sees the core events, which may miss some
+	note := ""
+	if partial != nil {
+		note = fmt.Sprintf(" Only core events were read, events.k8s.io/v1 failed: %v", partial)
+	}
+	if len(warnings) == 0 {
+		outcome := models.OutcomePass
+		if partial != nil {
+			outcome = models.OutcomeWarn
+		}
+		return models.ResourceCheck{Label: "Events", Details: fmt.Sprintf("No warning events in the last %s.%s", lookback, note), Outcome: outcome}
 	}
 
-	count := len(events.Items)
-	details := fmt.Sprintf("Count of Events: %d", count)
-	if count == 0 {
-		details = "No errors found in events."
-	} else {
-		errorEvents := []string{}
-		for _, event := range events.Items {
-			if event.Type == "Warning" {
-				errorEvents = append(errorEvents, event.Reason)
-			}
+	groups := groupEvents(warnings)
+	outcome := models.OutcomeWarn
+	for _, group := range groups {
+		if slices.Contains(cfg.Events.FailReasons, group.Reason) {
+			outcome = models.OutcomeFail
+			break
 		}
-		if len(errorEvents) > 0 {
-			details = fmt.Sprintf("Warning events found: %d", len(errorEvents))
-		} else {
-			details = "No critical issues found in events."
+	}
+	offenders := []models.ObjectRef{}
+	for _, group := range groups[:min(len(groups), cfg.Events.Top)] {
+		ref := group.Object
+		ref.Reason = fmt.Sprintf("%s x%d, last seen %s ago", group.Reason, group.Count, time.Since(group.LastSeen).Round(time.Second))
+		if group.Message != "" {
+			ref.Reason += ": " + group.Message
 		}
+		offenders = append(offenders, ref)
+	}
+	total := int32(0)
+	for _, group := range groups {
+		total += group.Count
+	}
+	noisiest := groups[0]
+	return models.ResourceCheck{
+		Label: "Events",
+		Details: fmt.Sprintf("%d warnings in the last %s: %s; noisiest: %s on %s (%d).%s",
+			total, lookback, reasonCounts(groups), noisiest.Reason, noisiest.Object, noisiest.Count, note),
+		Outcome: outcome,
+		Objects: offenders,
 	}
-	return models.ResourceCheck{Label: "Events", Details: details, Status: count == 0}
 }
 
-func checkIngresses(clientset *kubernetes.Clientset) models.ResourceCheck {
-	ingresses, err := clientset.NetworkingV1().Ingresses("").List(context.Background(), metav1.ListOptions{})
+func checkIngresses(ctx context.Context, snapshot *k8s.Snapshot) models.ResourceCheck {
+	ingresses, err := snapshot.Ingresses(ctx, "")
 	if err != nil {
-		return models.ResourceCheck{Label: "Ingresses", Details: "Error fetching ingresses", Status: false}
+		return models.ResourceCheck{Label: "Ingresses", Details: "Error fetching ingresses", Outcome: models.OutcomeError, Error: err}
 	}
 
 	count := len(ingresses.Items)
 	details := fmt.Sprintf("Total: %d", count)
+	outcome := models.OutcomePass
 	if count == 0 {
 		details = "No ingresses are available."
+		outcome = models.OutcomeWarn
 	}
-	return models.ResourceCheck{Label: "Ingresses", Details: details, Status: count > 0}
+	return models.ResourceCheck{Label: "Ingresses", Details: details, Outcome: outcome}
 }
 
-func checkDaemonSets(clientset *kubernetes.Clientset) models.ResourceCheck {
-	daemonsets, err := clientset.AppsV1().DaemonSets("").List(context.Background(), metav1.ListOptions{})
+func checkDaemonSets(ctx context.Context, snapshot *k8s.Snapshot) models.ResourceCheck {
+	daemonsets, err := snapshot.DaemonSets(ctx, "")
 	if err != nil {
-		return models.ResourceCheck{Label: "Daemon Sets", Details: "Error fetching daemon sets", Status: false}
-	}
-
-	count := len(daemonsets.Items)
-	details := fmt.Sprintf("Count of Daemonsets: %d", count)
-	if count == 0 {
-		details = "No daemon sets are available."
-		return models.ResourceCheck{Label: "Daemon Sets", Details: details, Status: false}
-	}
-
-	allHealthy := true
-	for _, ds := range daemonsets.Items {
-		if ds.Status.DesiredNumberScheduled != ds.Status.CurrentNumberScheduled {
-			allHealthy = false
-			break
-		}
+		return models.ResourceCheck{Label: "Daemon Sets", Details: "Error fetching daemon sets", Outcome: models.OutcomeError, Error: err}
 	}
-
-	if allHealthy {
-		details = "All daemon sets are healthy."
-	} else {
-		details = "Some daemon sets are not healthy."
+	rollouts := []rollout{}
+	for i := range daemonsets.Items {
+		rollouts = append(rollouts, daemonSetRollout(&daemonsets.Items[i]))
 	}
-
-	return models.ResourceCheck{Label: "Daemon Sets", Details: details, Status: allHealthy}
+	return rolloutResult("Daemon Sets", "daemon sets", rollouts)
 }
 
-func checkStatefulSets(clientset *kubernetes.Clientset) models.ResourceCheck {
-	statefulsets, err := clientset.AppsV1().StatefulSets("").List(context.Background(), metav1.ListOptions{})
+func checkStatefulSets(ctx context.Context, snapshot *k8s.Snapshot) models.ResourceCheck {
+	statefulsets, err := snapshot.StatefulSets(ctx, "")
 	if err != nil {
-		return models.ResourceCheck{Label: "Stateful Sets", Details: "Error fetching stateful sets", Status: false}
+		return models.ResourceCheck{Label: "Stateful Sets", Details: "Error fetching stateful sets", Outcome: models.OutcomeError, Error: err}
 	}
-
-	count := len(statefulsets.Items)
-	details := fmt.Sprintf("Count of StatefulSets: %d", count)
-	if count == 0 {
-		details = "No stateful sets are available."
-		return models.ResourceCheck{Label: "Stateful Sets", Details: details, Status: false}
+	rollouts := []rollout{}
+	for i := range statefulsets.Items {
+		rollouts = append(rollouts, statefulSetRollout(&statefulsets.Items[i]))
 	}
-
-	allHealthy := true
-	for _, ss := range statefulsets.Items {
-		if *ss.Spec.Replicas != ss.Status.ReadyReplicas {
-			allHealthy = false
-			break
-		}
-	}
-
-	if allHealthy {
-		details = "All stateful sets are healthy."
-	} else {
-		details = "Some stateful sets are not healthy."
-	}
-
-	return models.ResourceCheck{Label: "Stateful Sets", Details: details, Status: allHealthy}
+	return rolloutResult("Stateful Sets", "stateful sets", rollouts)
 }
@@ -0,0 +1,180 @@
+package testsuite
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"healthctl/pkg/k8s"
+	"healthctl/pkg/models"
+
+	v1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// warningEvent is a warning event read from either events API
+type warningEvent struct {
+	UID      types.UID
+	Reason   string
+	Message  string
+	Object   models.ObjectRef
+	Count    int32
+	LastSeen time.Time
+}
+
+// eventGroup aggregates the warning events of one reason on one involved object
+type eventGroup struct {
+	Reason   string
+	Object   models.ObjectRef
+	Count    int32
+	LastSeen time.Time
+	// Message is the message of the most recent event of the group that has one
+	Message string
+}
+
+// recentWarnings returns the warning events last seen after since, read from
+// events.k8s.io/v1 and core/v1. Both APIs serve the same events, so events are
+// deduplicated by UID. When events.k8s.io cannot be listed the core API alone is
+// used and the reason is returned as partial, the API not being served is no error.
+func recentWarnings(ctx context.Context, snapshot *k8s.Snapshot, since time.Time) (warnings []warningEvent, partial error, err error) {
+	core, err := snapshot.Events(ctx, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	seen := map[types.UID]bool{}
+	warnings = []warningEvent{}
+	add := func(event warningEvent) {
+		if seen[event.UID] || event.LastSeen.Before(since) {
+			return
+		}
+		seen[event.UID] = true
+		warnings = append(warnings, event)
+	}
+
+	series, err := snapshot.EventsV1(ctx)
+	switch {
+	case err == nil:
+		for i := range series.Items {
+			if series.Items[i].Type == v1.EventTypeWarning {
+				add(fromEventsV1(&series.Items[i]))
+			}
+		}
+	case !apierrors.IsNotFound(err):
+		partial = err
+	}
+	for i := range core.Items {
+		if core.Items[i].Type == v1.EventTypeWarning {
+			add(fromCoreEvent(&core.Items[i]))
+		}
+	}
+	return warnings, partial, nil
+}
+
+func fromCoreEvent(event *v1.Event) warningEvent {
+	last := event.LastTimestamp.Time
+	if event.Series != nil && !event.Series.LastObservedTime.IsZero() {
+		last = event.Series.LastObservedTime.Time
+	}
+	if last.IsZero() {
+		last = event.EventTime.Time
+	}
+	if last.IsZero() {
+		last = event.FirstTimestamp.Time
+	}
+	if last.IsZero() {
+		last = event.CreationTimestamp.Time
+	}
+	count := event.Count
+	if event.Series != nil && event.Series.Count > count {
+		count = event.Series.Count
+	}
+	return warningEvent{
+		UID:      event.UID,
+		Reason:   event.Reason,
+		Message:  event.Message,
+		Object:   models.ObjectRef{Kind: event.InvolvedObject.Kind, Namespace: event.InvolvedObject.Namespace, Name: event.InvolvedObject.Name},
+		Count:    max(count, 1),
+		LastSeen: last,
+	}
+}
+
+func fromEventsV1(event *eventsv1.Event) warningEvent {
+	last := event.EventTime.Time
+	count := event.DeprecatedCount
+	if event.Series != nil {
+		last = event.Series.LastObservedTime.Time
+		count = max(count, event.Series.Count)
+	}
+	if !event.DeprecatedLastTimestamp.IsZero() && event.DeprecatedLastTimestamp.After(last) {
+		last = event.DeprecatedLastTimestamp.Time
+	}
+	if last.IsZero() {
+		last = event.CreationTimestamp.Time
+	}
+	return warningEvent{
+		UID:      event.UID,
+		Reason:   event.Reason,
+		Message:  event.Note,
+		Object:   models.ObjectRef{Kind: event.Regarding.Kind, Namespace: event.Regarding.Namespace, Name: event.Regarding.Name},
+		Count:    max(count, 1),
+		LastSeen: last,
+	}
+}
+
+// groupEvents aggregates the events by reason and involved object, noisiest first
+func groupEvents(events []warningEvent) []eventGroup {
+	groups := map[string]*eventGroup{}
+	for _, event := range events {
+		key := event.Reason + "|" + event.Object.String()
+		group, ok := groups[key]
+		if !ok {
+			group = &eventGroup{Reason: event.Reason, Object: event.Object}
+			groups[key] = group
+		}
+		group.Count += event.Count
+		if !event.LastSeen.Before(group.LastSeen) {
+			group.LastSeen = event.LastSeen
+			if event.Message != "" {
+				group.Message = event.Message
+			}
+		}
+	}
+	ranked := make([]eventGroup, 0, len(groups))
+	for _, group := range groups {
+		ranked = append(ranked, *group)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		return ranked[i].LastSeen.After(ranked[j].LastSeen)
+	})
+	return ranked
+}
+
+// reasonCounts sums the event counts of the groups by reason, as "BackOff 42, FailedMount 3"
+func reasonCounts(groups []eventGroup) string {
+	counts := map[string]int32{}
+	for _, group := range groups {
+		counts[group.Reason] += group.Count
+	}
+	reasons := make([]string, 0, len(counts))
+	for reason := range counts {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if counts[reasons[i]] != counts[reasons[j]] {
+			return counts[reasons[i]] > counts[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	parts := []string{}
+	for _, reason := range reasons {
+		parts = append(parts, fmt.Sprintf("%s %d", reason, counts[reason]))
+	}
+	return strings.Join(parts, ", ")
+}
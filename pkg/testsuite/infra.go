@@ -2,116 +2,180 @@ package testsuite
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
 
+	"healthctl/pkg/config"
+	"healthctl/pkg/k8s"
 	"healthctl/pkg/models"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
-func CheckINFRA(clientset *kubernetes.Clientset) []models.ResourceCheck {
-	checks := []models.ResourceCheck{
-		CheckOPA(clientset),
-		CheckMetallb(clientset),
-		CheckKubeAddons(clientset),
-		CheckFedRbac(clientset),
-		CheckFedCRD(clientset),
-	}
-	return checks
+func init() {
+	Register(
+		NewCheck(SuiteInfra, "opa", "OPA pods and services are present", []string{"policy"}, configCheck(CheckOPA)),
+		NewCheck(SuiteInfra, "metallb", "MetalLB pods and services are present", []string{"network"}, configCheck(CheckMetallb)),
+		NewCheck(SuiteInfra, "kube-addons", "Kube addons pods and services are present", []string{"addons"}, configCheck(CheckKubeAddons)),
+		NewCheck(SuiteInfra, "fed-rbac", "Fed RBAC pods are present", []string{"policy"}, configCheck(CheckFedRbac)),
+		NewCheck(SuiteInfra, "fed-crd", "Required CRDs are installed, established and serve their versions", []string{"crd"}, CheckFedCRD),
+	)
 }
 
 // Check functions
-func CheckOPA(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckOPA(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if OPA pod is running in fed-opa namespace
-	pods, err := clientset.CoreV1().Pods("fed-opa").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.OPA)
 	if err != nil {
-		return models.ResourceCheck{"OPA", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "OPA", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"OPA", "No OPA pods found", false}
+		return models.ResourceCheck{Label: "OPA", Details: "No OPA pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.OPA, Reason: "no pods found"}}}
 	}
 
 	// Check if OPA service is up
-	services, err := clientset.CoreV1().Services("fed-opa").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.OPA)
 	if err != nil {
-		return models.ResourceCheck{"OPA", "Error fetching services", false}
+		return models.ResourceCheck{Label: "OPA", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"OPA", "No OPA services found", false}
+		return models.ResourceCheck{Label: "OPA", Details: "No OPA services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.OPA, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"OPA", "OPA is Up", true}
+	return models.ResourceCheck{Label: "OPA", Details: "OPA is Up", Outcome: models.OutcomePass}
 }
 
-func CheckMetallb(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckMetallb(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if MetalLB pod is running in fed-metallb-system namespace
-	pods, err := clientset.CoreV1().Pods("fed-metallb-system").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.MetalLB)
 	if err != nil {
-		return models.ResourceCheck{"MetalLB", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "MetalLB", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"metallb", "MetalLB is down", false}
+		return models.ResourceCheck{Label: "metallb", Details: "MetalLB is down", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.MetalLB, Reason: "no pods found"}}}
 	}
 
 	// Check if MetalLB service is up
-	services, err := clientset.CoreV1().Services("fed-metallb").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.MetalLBServices)
 	if err != nil {
-		return models.ResourceCheck{"MetalLB", "Error fetching services", false}
+		return models.ResourceCheck{Label: "MetalLB", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"MetalLB", "No MetalLB services found", false}
+		return models.ResourceCheck{Label: "MetalLB", Details: "No MetalLB services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.MetalLBServices, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"MetalLB", "MetalLB is Up", true}
+	return models.ResourceCheck{Label: "MetalLB", Details: "MetalLB is Up", Outcome: models.OutcomePass}
 }
 
-func CheckKubeAddons(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckKubeAddons(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if kube-addons pod is running in fed-kube-addons namespace
-	pods, err := clientset.CoreV1().Pods("fed-kube-addons").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.KubeAddons)
 	if err != nil {
-		return models.ResourceCheck{"KubeAddons", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "KubeAddons", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"KubeAddons", "No KubeAddons pods found", false}
+		return models.ResourceCheck{Label: "KubeAddons", Details: "No KubeAddons pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.KubeAddons, Reason: "no pods found"}}}
 	}
 
 	// Check if kube-addons service is up
-	services, err := clientset.CoreV1().Services("fed-kube-addons").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.KubeAddons)
 	if err != nil {
-		return models.ResourceCheck{"KubeAddons", "Error fetching services", false}
+		return models.ResourceCheck{Label: "KubeAddons", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"KubeAddons", "No KubeAddons services found", false}
+		return models.ResourceCheck{Label: "KubeAddons", Details: "No KubeAddons services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.KubeAddons, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"KubeAddons", "KubeAddons is Up", true}
+	return models.ResourceCheck{Label: "KubeAddons", Details: "KubeAddons is Up", Outcome: models.OutcomePass}
 }
 
-func CheckFedRbac(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckFedRbac(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if fed-rbac pod is running in fed-rbac namespace
-	pods, err := clientset.CoreV1().Pods("fed-rbac").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.RBAC)
 	if err != nil {
-		return models.ResourceCheck{"FedRbac", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "FedRbac", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"FedRbac", "No Rbac pods found", false}
+		return models.ResourceCheck{Label: "FedRbac", Details: "No Rbac pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.RBAC, Reason: "no pods found"}}}
 	}
 
-	return models.ResourceCheck{"FedRbac", "FedRbac is Up", true}
+	return models.ResourceCheck{Label: "FedRbac", Details: "FedRbac is Up", Outcome: models.OutcomePass}
 }
 
-func CheckFedCRD(clientset *kubernetes.Clientset) models.ResourceCheck {
+// CheckFedCRD reports the required CustomResourceDefinitions that are missing, not
+// established, or do not serve one of the configured versions. Definitions that
+// cannot be read are listed too and make the check an error.
+func CheckFedCRD(ctx context.Context, clients *Clients) []models.ResourceCheck {
+	crds := clients.Config.CRDs
+	if len(crds) == 0 {
+		return []models.ResourceCheck{{Label: "FedCRD", Details: "No required CRDs are configured", Outcome: models.OutcomeSkipped}}
+	}
+
+	problems := []models.ObjectRef{}
+	var lastErr error
+	for _, required := range crds {
+		status, err := clients.GetCRD(ctx, required.Name)
+		switch {
+		case apierrors.IsNotFound(err):
+			problems = append(problems, models.ObjectRef{Kind: "CustomResourceDefinition", Name: required.Name, Reason: "not installed"})
+		case err != nil:
+			problems = append(problems, models.ObjectRef{Kind: "CustomResourceDefinition", Name: required.Name, Reason: fmt.Sprintf("error fetching: %v", err)})
+			lastErr = err
+		default:
+			if reason := crdProblem(status, required); reason != "" {
+				problems = append(problems, models.ObjectRef{Kind: "CustomResourceDefinition", Name: required.Name, Reason: reason})
+			}
+		}
+	}
 
-	return models.ResourceCheck{"FedCRD", "FedCRD is Up", true}
+	if len(problems) > 0 {
+		names := []string{}
+		for _, problem := range problems {
+			names = append(names, problem.Name)
+		}
+		outcome := models.OutcomeFail
+		if lastErr != nil {
+			outcome = models.OutcomeError
+		}
+		return []models.ResourceCheck{{
+			Label:   "FedCRD",
+			Details: fmt.Sprintf("%d of %d required CRDs not ready: %s", len(problems), len(crds), strings.Join(names, ", ")),
+			Outcome: outcome,
+			Error:   lastErr,
+			Objects: problems,
+		}}
+	}
+	return []models.ResourceCheck{{Label: "FedCRD", Details: fmt.Sprintf("All %d required CRDs are established", len(crds)), Outcome: models.OutcomePass}}
+}
+
+// crdProblem describes why the CRD does not meet the requirement, or returns "" when it does
+func crdProblem(status k8s.CRDStatus, required config.CRD) string {
+	reasons := []string{}
+	if !status.Established {
+		reasons = append(reasons, "not established")
+	}
+	if !status.NamesAccepted {
+		reasons = append(reasons, "names not accepted")
+	}
+	for _, version := range required.Versions {
+		if !slices.Contains(status.Served, version) {
+			reasons = append(reasons, fmt.Sprintf("version %s not served (serves %s)", version, strings.Join(status.Served, ", ")))
+		}
+	}
+	if len(reasons) > 0 && len(status.Messages) > 0 {
+		reasons = append(reasons, status.Messages...)
+	}
+	return strings.Join(reasons, "; ")
 }
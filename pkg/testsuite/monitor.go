@@ -0,0 +1,233 @@
+package testsuite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"healthctl/pkg/models"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// MonitorSection is the header of the section of the monitor output listing the
+// critical services
+const MonitorSection = "Critical Ready Services Monitoring Status"
+
+// ErrNoMonitorSection is returned by ParseMonitor when the output has no critical
+// services section
+var ErrNoMonitorSection = errors.New("no " + strconv.Quote(MonitorSection) + " section")
+
+// MonitorService is one service of the critical services section of the monitor
+// output, for example
+//
+//	ServiceName: nsmf-pdusession | Current No Of Instances : Active: 2 | Minimum No Of Instances Required: 1, Max: 4 | Status: UP
+//
+// Counts that are not reported, or cannot be read, are -1.
+type MonitorService struct {
+	Name   string
+	Status string
+	// Current is the number of running instances, or of available servers
+	Current int
+	// Minimum is the number of instances, clusters or servers required
+	Minimum int
+	// Unit is what Current and Minimum count: instances, servers or clusters
+	Unit string
+	// Fields holds every key and value of the line as printed
+	Fields map[string]string
+}
+
+// Up reports whether the monitor considers the service up
+func (s MonitorService) Up() bool {
+	return strings.EqualFold(s.Status, "UP")
+}
+
+// Shortfall returns by how much the service is below its minimum, 0 when it is
+// not or when a count is unknown
+func (s MonitorService) Shortfall() int {
+	if s.Current < 0 || s.Minimum < 0 || s.Current >= s.Minimum {
+		return 0
+	}
+	return s.Minimum - s.Current
+}
+
+// ParseMonitor reads the services of the critical services section of the monitor
+// output. The section runs from its header to the first blank line, or the next
+// monitoring section, after its services. Every line with a
+// ServiceName field is returned, even when some of its fields are missing.
+func ParseMonitor(output string) ([]MonitorService, error) {
+	lines := strings.Split(output, "\n")
+	start := -1
+	for index, line := range lines {
+		if strings.Contains(line, MonitorSection) {
+			start = index
+			break
+		}
+	}
+	if start < 0 {
+		return nil, ErrNoMonitorSection
+	}
+
+	services := []MonitorService{}
+	for _, line := range lines[start+1:] {
+		line = strings.TrimSpace(line)
+		if len(services) > 0 && (line == "" || strings.Contains(line, "Monitoring Status")) {
+			break
+		}
+		if service, ok := parseMonitorService(line); ok {
+			services = append(services, service)
+		}
+	}
+	return services, nil
+}
+
+// parseMonitorService parses one "key: value | key: value" line, ok is false when
+// the line has no ServiceName field
+func parseMonitorService(line string) (MonitorService, bool) {
+	service := MonitorService{Current: -1, Minimum: -1, Fields: map[string]string{}}
+	for _, field := range strings.Split(line, "|") {
+		key, value, found := strings.Cut(field, ":")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		service.Fields[key] = value
+		lower := strings.ToLower(key)
+		switch {
+		case lower == "servicename":
+			service.Name = value
+		case lower == "status":
+			service.Status = value
+		case strings.HasPrefix(lower, "current"):
+			// the count is the last value, after any "Active:" style label
+			service.Current = leadingInt(value[strings.LastIndex(value, ":")+1:])
+			if strings.Contains(lower, "server") {
+				service.Unit = "servers"
+			} else {
+				service.Unit = "instances"
+			}
+		case strings.HasPrefix(lower, "minimum"):
+			// "1, Max: 4" lists the minimum first
+			service.Minimum = leadingInt(value)
+			if strings.Contains(lower, "cluster") {
+				service.Unit = "clusters"
+			} else if strings.Contains(lower, "server") {
+				service.Unit = "servers"
+			}
+		}
+	}
+	if service.Unit == "" {
+		service.Unit = "instances"
+	}
+	return service, service.Name != ""
+}
+
+// leadingInt returns the number at the start of s, or -1
+func leadingInt(s string) int {
+	s = strings.TrimSpace(s)
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, err := strconv.Atoi(s[:end])
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// queryMonitor reads the critical services from the monitor endpoint at url,
+// querying it from inside a running and ready pod matching the selector, and
+// returns one result per service
+func queryMonitor(ctx context.Context, clients *Clients, label, namespace, selector, container, url string) []models.ResourceCheck {
+	pods, err := clients.Snapshot.PodsWithLabels(ctx, namespace, selector)
+	if err != nil {
+		return []models.ResourceCheck{{
+			Label:   label,
+			Details: fmt.Sprintf("Failed to list %s pods", selector),
+			Outcome: models.OutcomeError,
+			Error:   err,
+		}}
+	}
+	if len(pods.Items) == 0 {
+		return []models.ResourceCheck{{
+			Label:   label,
+			Details: fmt.Sprintf("Failed to find a %s pod in %s", selector, namespace),
+			Outcome: models.OutcomeFail,
+		}}
+	}
+	var pod *v1.Pod
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == v1.PodRunning && podReady(&pods.Items[i]) {
+			pod = &pods.Items[i]
+			break
+		}
+	}
+	if pod == nil {
+		return []models.ResourceCheck{{
+			Label:   label,
+			Details: fmt.Sprintf("None of the %d %s pods in %s is running and ready", len(pods.Items), selector, namespace),
+			Outcome: models.OutcomeFail,
+		}}
+	}
+	podRef := []models.ObjectRef{{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name}}
+	output, _, err := clients.ExecuteRemoteCommand(ctx, pod.Namespace, pod.Name, container, "curl -sS --fail "+url)
+	if err != nil {
+		return []models.ResourceCheck{{
+			Label:    label,
+			Details:  fmt.Sprintf("Unable to reach the monitor endpoint %s in pod %s", url, pod.Name),
+			Outcome:  models.OutcomeError,
+			Severity: models.SeverityCritical,
+			Error:    err,
+			Objects:  podRef,
+		}}
+	}
+
+	services, err := ParseMonitor(output)
+	if err != nil || len(services) == 0 {
+		if err == nil {
+			err = errors.New("no services listed")
+		}
+		return []models.ResourceCheck{{
+			Label:    label,
+			Details:  fmt.Sprintf("Unable to read the critical services from %s in pod %s", url, pod.Name),
+			Outcome:  models.OutcomeError,
+			Severity: models.SeverityCritical,
+			Error:    err,
+			Objects:  podRef,
+		}}
+	}
+
+	var checks []models.ResourceCheck
+	for _, service := range services {
+		checks = append(checks, models.ResourceCheck{
+			Label:    service.Name,
+			Details:  monitorServiceDetails(service),
+			Outcome:  models.OutcomeFor(service.Up() && service.Shortfall() == 0),
+			Severity: models.SeverityCritical,
+		})
+	}
+	return checks
+}
+
+// monitorServiceDetails describes the status and counts of a critical service
+func monitorServiceDetails(service MonitorService) string {
+	count := func(n int) string {
+		if n < 0 {
+			return "?"
+		}
+		return strconv.Itoa(n)
+	}
+	status := service.Status
+	if status == "" {
+		status = "in unknown state"
+	}
+	details := fmt.Sprintf("Service %s is %s: %s of %s %s required", service.Name, status, count(service.Current), count(service.Minimum), service.Unit)
+	if shortfall := service.Shortfall(); shortfall > 0 {
+		details += fmt.Sprintf(", %d below its minimum", shortfall)
+	}
+	return details
+}
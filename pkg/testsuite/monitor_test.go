@@ -0,0 +1,203 @@
+package testsuite
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// smfmonitorInfo is the output of smfmonitor-info as printed by the SMF
+const smfmonitorInfo = `SMF Monitor Info
+================
+
+Critical Ready Services Monitoring Status
+ServiceName: nsmf-pdusession | Current No Of Instances : Active: 2 | Minimum No Of Instances Required: 1, Max: 4 | Status: UP
+ServiceName: smf-nodemgr | Current No Of Available Servers: 1 | Minimum No Of Servers Required: 2 | Status: DOWN
+ServiceName: cdl-ep | Current No Of Instances : Active: 3 | Minimum No Of Clusters Required: 1 | Status: UP
+
+Non Critical Services Monitoring Status
+ServiceName: smf-protocol | Current No Of Instances : Active: 0 | Minimum No Of Instances Required: 1 | Status: DOWN
+`
+
+func TestParseMonitor(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		services []string
+		err      error
+	}{
+		{
+			name:     "section ends at blank line",
+			output:   smfmonitorInfo,
+			services: []string{"nsmf-pdusession", "smf-nodemgr", "cdl-ep"},
+		},
+		{
+			name: "section ends at next header",
+			output: "Critical Ready Services Monitoring Status\n" +
+				"ServiceName: nsmf-pdusession | Current No Of Instances : Active: 2 | Minimum No Of Instances Required: 1 | Status: UP\n" +
+				"Non Critical Services Monitoring Status\n" +
+				"ServiceName: smf-protocol | Current No Of Instances : Active: 0 | Minimum No Of Instances Required: 1 | Status: DOWN\n",
+			services: []string{"nsmf-pdusession"},
+		},
+		{
+			name: "blank line before the services",
+			output: "Critical Ready Services Monitoring Status\n" +
+				"\n" +
+				"ServiceName: nsmf-pdusession | Current No Of Instances : Active: 2 | Minimum No Of Instances Required: 1 | Status: UP\n",
+			services: []string{"nsmf-pdusession"},
+		},
+		{
+			name: "lines without service name are skipped",
+			output: "Critical Ready Services Monitoring Status\n" +
+				"---------------------------------------\n" +
+				"ServiceName: nsmf-pdusession | Status: UP\n",
+			services: []string{"nsmf-pdusession"},
+		},
+		{
+			name:     "empty section",
+			output:   "Critical Ready Services Monitoring Status\n",
+			services: []string{},
+		},
+		{
+			name:   "missing section",
+			output: "Non Critical Services Monitoring Status\nServiceName: smf-protocol | Status: DOWN\n",
+			err:    ErrNoMonitorSection,
+		},
+		{
+			name:   "empty output",
+			output: "",
+			err:    ErrNoMonitorSection,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			services, err := ParseMonitor(tt.output)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseMonitor() error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			names := []string{}
+			for _, service := range services {
+				names = append(names, service.Name)
+			}
+			if !reflect.DeepEqual(names, tt.services) {
+				t.Errorf("ParseMonitor() services = %v, want %v", names, tt.services)
+			}
+		})
+	}
+}
+
+func TestParseMonitorService(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    MonitorService
+		ok      bool
+		up      bool
+		missing int
+	}{
+		{
+			name:    "active label and max",
+			line:    "ServiceName: nsmf-pdusession | Current No Of Instances : Active: 2 | Minimum No Of Instances Required: 1, Max: 4 | Status: UP",
+			want:    MonitorService{Name: "nsmf-pdusession", Status: "UP", Current: 2, Minimum: 1, Unit: "instances"},
+			ok:      true,
+			up:      true,
+			missing: 0,
+		},
+		{
+			name:    "servers",
+			line:    "ServiceName: smf-nodemgr | Current No Of Available Servers: 1 | Minimum No Of Servers Required: 2 | Status: DOWN",
+			want:    MonitorService{Name: "smf-nodemgr", Status: "DOWN", Current: 1, Minimum: 2, Unit: "servers"},
+			ok:      true,
+			missing: 1,
+		},
+		{
+			name:    "clusters",
+			line:    "ServiceName: cdl-ep | Current No Of Instances : Active: 3 | Minimum No Of Clusters Required: 1 | Status: up",
+			want:    MonitorService{Name: "cdl-ep", Status: "up", Current: 3, Minimum: 1, Unit: "clusters"},
+			ok:      true,
+			up:      true,
+			missing: 0,
+		},
+		{
+			name:    "truncated line",
+			line:    "ServiceName: nsmf-pdusession | Current No Of Instances : Active:",
+			want:    MonitorService{Name: "nsmf-pdusession", Current: -1, Minimum: -1, Unit: "instances"},
+			ok:      true,
+			missing: 0,
+		},
+		{
+			name:    "unreadable counts",
+			line:    "ServiceName: nsmf-pdusession | Current No Of Instances : Active: n/a | Minimum No Of Instances Required: many | Status: UP",
+			want:    MonitorService{Name: "nsmf-pdusession", Status: "UP", Current: -1, Minimum: -1, Unit: "instances"},
+			ok:      true,
+			up:      true,
+			missing: 0,
+		},
+		{
+			name: "no service name",
+			line: "Current No Of Instances : Active: 2 | Status: UP",
+			want: MonitorService{Status: "UP", Current: 2, Minimum: -1, Unit: "instances"},
+			up:   true,
+		},
+		{
+			name: "header",
+			line: "Critical Ready Services Monitoring Status",
+			want: MonitorService{Current: -1, Minimum: -1, Unit: "instances"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, ok := parseMonitorService(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("parseMonitorService() ok = %v, want %v", ok, tt.ok)
+			}
+			service.Fields = nil
+			if !reflect.DeepEqual(service, tt.want) {
+				t.Errorf("parseMonitorService() = %+v, want %+v", service, tt.want)
+			}
+			if service.Up() != tt.up {
+				t.Errorf("Up() = %v, want %v", service.Up(), tt.up)
+			}
+			if missing := service.Shortfall(); missing != tt.missing {
+				t.Errorf("Shortfall() = %d, want %d", missing, tt.missing)
+			}
+		})
+	}
+}
+
+func TestParseMonitorServiceFields(t *testing.T) {
+	service, _ := parseMonitorService("ServiceName: nsmf-pdusession | Current No Of Instances : Active: 2 | Minimum No Of Instances Required: 1, Max: 4 | Status: UP")
+	want := map[string]string{
+		"ServiceName":                      "nsmf-pdusession",
+		"Current No Of Instances":          "Active: 2",
+		"Minimum No Of Instances Required": "1, Max: 4",
+		"Status":                           "UP",
+	}
+	if !reflect.DeepEqual(service.Fields, want) {
+		t.Errorf("parseMonitorService() fields = %v, want %v", service.Fields, want)
+	}
+}
+
+func TestLeadingInt(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"4", 4},
+		{" 12 ", 12},
+		{"1, Max: 4", 1},
+		{"3 instances", 3},
+		{"", -1},
+		{"  ", -1},
+		{"n/a", -1},
+		{"-1", -1},
+	}
+	for _, tt := range tests {
+		if got := leadingInt(tt.in); got != tt.want {
+			t.Errorf("leadingInt(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
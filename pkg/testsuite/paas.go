@@ -3,353 +3,351 @@ package testsuite
 import (
 	"context"
 
+	"healthctl/pkg/config"
+	"healthctl/pkg/k8s"
 	"healthctl/pkg/models"
-
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 )
 
-func CheckPAAS(clientset *kubernetes.Clientset) []models.ResourceCheck {
-	checks := []models.ResourceCheck{
-		CheckGrafana(clientset),
-		CheckKibana(clientset),
-		CheckPrometheus(clientset),
-		CheckDbEtcd(clientset),
-		CheckIstio(clientset),
-		CheckKubeProm(clientset),
-		CheckRedisOperator(clientset),
-		CheckRedisCluster(clientset),
-		CheckJaeger(clientset),
-		CheckElastic(clientset),
-		CheckElastAlert(clientset),
-		CheckAlerta(clientset),
-		CheckKiali(clientset),
-	}
-	return checks
+func init() {
+	Register(
+		NewCheck(SuitePaaS, "grafana", "Grafana pods and services are present", []string{"observability"}, configCheck(CheckGrafana)),
+		NewCheck(SuitePaaS, "kibana", "Kibana pods and services are present", []string{"observability"}, configCheck(CheckKibana)),
+		NewCheck(SuitePaaS, "prometheus", "Prometheus pods and services are present", []string{"observability"}, configCheck(CheckPrometheus)),
+		NewCheck(SuitePaaS, "etcd", "Etcd pods and services are present", []string{"database"}, configCheck(CheckDbEtcd)),
+		NewCheck(SuitePaaS, "istio", "Istio pods and services are present", []string{"network"}, configCheck(CheckIstio)),
+		NewCheck(SuitePaaS, "kube-prom", "KubeProm pods and services are present", []string{"observability"}, configCheck(CheckKubeProm)),
+		NewCheck(SuitePaaS, "redis-operator", "Redis operator pods and services are present", []string{"database"}, configCheck(CheckRedisOperator)),
+		NewCheck(SuitePaaS, "redis-cluster", "Redis cluster pods and services are present", []string{"database"}, configCheck(CheckRedisCluster)),
+		NewCheck(SuitePaaS, "jaeger", "Jaeger pods and services are present", []string{"observability"}, configCheck(CheckJaeger)),
+		NewCheck(SuitePaaS, "elastic", "Elastic pods and services are present", []string{"database"}, configCheck(CheckElastic)),
+		NewCheck(SuitePaaS, "elastalert", "ElastAlert pods and services are present", []string{"observability"}, configCheck(CheckElastAlert)),
+		NewCheck(SuitePaaS, "alerta", "Alerta pods and services are present", []string{"observability"}, configCheck(CheckAlerta)),
+		NewCheck(SuitePaaS, "kiali", "Kiali pods and services are present", []string{"observability"}, configCheck(CheckKiali)),
+	)
 }
 
 // Check functions
-func CheckGrafana(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckGrafana(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if Grafana pod is running in fed-grafana namespace
-	pods, err := clientset.CoreV1().Pods("fed-grafana").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.Grafana)
 	if err != nil {
-		return models.ResourceCheck{"Grafana", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "Grafana", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"Grafana", "No Grafana pods found", false}
+		return models.ResourceCheck{Label: "Grafana", Details: "No Grafana pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Grafana, Reason: "no pods found"}}}
 	}
 
 	// Check if Grafana service is up
-	services, err := clientset.CoreV1().Services("fed-grafana").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.Grafana)
 	if err != nil {
-		return models.ResourceCheck{"Grafana", "Error fetching services", false}
+		return models.ResourceCheck{Label: "Grafana", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"Grafana", "No Grafana services found", false}
+		return models.ResourceCheck{Label: "Grafana", Details: "No Grafana services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Grafana, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"Grafana", "Grafana is Up", true}
+	return models.ResourceCheck{Label: "Grafana", Details: "Grafana is Up", Outcome: models.OutcomePass}
 }
 
-func CheckKibana(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckKibana(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if Kibana pod is running in fed-kibana namespace
-	pods, err := clientset.CoreV1().Pods("fed-kibana").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.Kibana)
 	if err != nil {
-		return models.ResourceCheck{"Kibana", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "Kibana", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"Kibana", "No Kibana pods found", false}
+		return models.ResourceCheck{Label: "Kibana", Details: "No Kibana pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Kibana, Reason: "no pods found"}}}
 	}
 
 	// Check if Kibana service is up
-	services, err := clientset.CoreV1().Services("fed-kibana").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.Kibana)
 	if err != nil {
-		return models.ResourceCheck{"Kibana", "Error fetching services", false}
+		return models.ResourceCheck{Label: "Kibana", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"Kibana", "No Kibana services found", false}
+		return models.ResourceCheck{Label: "Kibana", Details: "No Kibana services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Kibana, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"Kibana", "Kibana is Up", true}
+	return models.ResourceCheck{Label: "Kibana", Details: "Kibana is Up", Outcome: models.OutcomePass}
 }
 
-func CheckPrometheus(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckPrometheus(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if Prometheus pod is running in fed-prometheus namespace
-	pods, err := clientset.CoreV1().Pods("fed-prometheus").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.Prometheus)
 	if err != nil {
-		return models.ResourceCheck{"Prometheus", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "Prometheus", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"Prometheus", "No Prometheus pods found", false}
+		return models.ResourceCheck{Label: "Prometheus", Details: "No Prometheus pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Prometheus, Reason: "no pods found"}}}
 	}
 
 	// Check if Prometheus service is up
-	services, err := clientset.CoreV1().Services("fed-prometheus").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.Prometheus)
 	if err != nil {
-		return models.ResourceCheck{"Prometheus", "Error fetching services", false}
+		return models.ResourceCheck{Label: "Prometheus", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"Prometheus", "No Prometheus services found", false}
+		return models.ResourceCheck{Label: "Prometheus", Details: "No Prometheus services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Prometheus, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"Prometheus", "Prometheus is Up", true}
+	return models.ResourceCheck{Label: "Prometheus", Details: "Prometheus is Up", Outcome: models.OutcomePass}
 }
 
-func CheckDbEtcd(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckDbEtcd(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if etcd pod is running in fed-etcd namespace
-	pods, err := clientset.CoreV1().Pods("fed-etcd").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.Etcd)
 	if err != nil {
-		return models.ResourceCheck{"Etcd", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "Etcd", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"Etcd", "No Etcd pods found", false}
+		return models.ResourceCheck{Label: "Etcd", Details: "No Etcd pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Etcd, Reason: "no pods found"}}}
 	}
 
 	// Check if etcd service is up
-	services, err := clientset.CoreV1().Services("fed-etcd").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.Etcd)
 	if err != nil {
-		return models.ResourceCheck{"Etcd", "Error fetching services", false}
+		return models.ResourceCheck{Label: "Etcd", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"Etcd", "No Etcd services found", false}
+		return models.ResourceCheck{Label: "Etcd", Details: "No Etcd services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Etcd, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"Etcd", "Etcd is Up", true}
+	return models.ResourceCheck{Label: "Etcd", Details: "Etcd is Up", Outcome: models.OutcomePass}
 }
 
-func CheckIstio(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckIstio(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if Istio pod is running in fed-istio-system namespace
-	pods, err := clientset.CoreV1().Pods("fed-istio-system").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.Istio)
 	if err != nil {
-		return models.ResourceCheck{"Istio", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "Istio", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"Istio", "No Istio pods found", false}
+		return models.ResourceCheck{Label: "Istio", Details: "No Istio pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Istio, Reason: "no pods found"}}}
 	}
 
 	// Check if Istio service is up
-	services, err := clientset.CoreV1().Services("fed-istio-system").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.Istio)
 	if err != nil {
-		return models.ResourceCheck{"Istio", "Error fetching services", false}
+		return models.ResourceCheck{Label: "Istio", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"Istio", "No Istio services found", false}
+		return models.ResourceCheck{Label: "Istio", Details: "No Istio services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Istio, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"Istio", "Istio is Up", true}
+	return models.ResourceCheck{Label: "Istio", Details: "Istio is Up", Outcome: models.OutcomePass}
 }
 
-func CheckKubeProm(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckKubeProm(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if KubeProm pod is running in fed-kube-prom namespace
-	pods, err := clientset.CoreV1().Pods("fed-kube-prom").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.KubeProm)
 	if err != nil {
-		return models.ResourceCheck{"KubeProm", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "KubeProm", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"KubeProm", "No KubeProm pods found", false}
+		return models.ResourceCheck{Label: "KubeProm", Details: "No KubeProm pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.KubeProm, Reason: "no pods found"}}}
 	}
 
 	// Check if KubeProm service is up
-	services, err := clientset.CoreV1().Services("fed-kube-prom").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.KubeProm)
 	if err != nil {
-		return models.ResourceCheck{"KubeProm", "Error fetching services", false}
+		return models.ResourceCheck{Label: "KubeProm", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"KubeProm", "No KubeProm services found", false}
+		return models.ResourceCheck{Label: "KubeProm", Details: "No KubeProm services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.KubeProm, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"KubeProm", "KubeProm is Up", true}
+	return models.ResourceCheck{Label: "KubeProm", Details: "KubeProm is Up", Outcome: models.OutcomePass}
 }
 
-func CheckRedisOperator(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckRedisOperator(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if RedisOperator pod is running in fed-redis-operator namespace
-	pods, err := clientset.CoreV1().Pods("fed-redis-operator").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.RedisOperator)
 	if err != nil {
-		return models.ResourceCheck{"RedisOperator", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "RedisOperator", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"RedisOperator", "No RedisOperator pods found", false}
+		return models.ResourceCheck{Label: "RedisOperator", Details: "No RedisOperator pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.RedisOperator, Reason: "no pods found"}}}
 	}
 
 	// Check if RedisOperator service is up
-	services, err := clientset.CoreV1().Services("fed-redis-operator").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.RedisOperator)
 	if err != nil {
-		return models.ResourceCheck{"RedisOperator", "Error fetching services", false}
+		return models.ResourceCheck{Label: "RedisOperator", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"RedisOperator", "No RedisOperator services found", false}
+		return models.ResourceCheck{Label: "RedisOperator", Details: "No RedisOperator services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.RedisOperator, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"RedisOperator", "RedisOperator is Up", true}
+	return models.ResourceCheck{Label: "RedisOperator", Details: "RedisOperator is Up", Outcome: models.OutcomePass}
 }
 
-func CheckRedisCluster(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckRedisCluster(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if RedisCluster pod is running in fed-redis-cluster namespace
-	pods, err := clientset.CoreV1().Pods("fed-redis-cluster").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.RedisCluster)
 	if err != nil {
-		return models.ResourceCheck{"RedisCluster", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "RedisCluster", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"RedisCluster", "No RedisCluster pods found", false}
+		return models.ResourceCheck{Label: "RedisCluster", Details: "No RedisCluster pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.RedisCluster, Reason: "no pods found"}}}
 	}
 
 	// Check if RedisCluster service is up
-	services, err := clientset.CoreV1().Services("fed-redis-cluster").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.RedisCluster)
 	if err != nil {
-		return models.ResourceCheck{"RedisCluster", "Error fetching services", false}
+		return models.ResourceCheck{Label: "RedisCluster", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"RedisCluster", "No RedisCluster services found", false}
+		return models.ResourceCheck{Label: "RedisCluster", Details: "No RedisCluster services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.RedisCluster, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"RedisCluster", "RedisCluster is Up", true}
+	return models.ResourceCheck{Label: "RedisCluster", Details: "RedisCluster is Up", Outcome: models.OutcomePass}
 }
 
-func CheckJaeger(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckJaeger(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if Yaeger pod is running in fed-yaeger namespace
-	pods, err := clientset.CoreV1().Pods("fed-yaeger").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.Jaeger)
 	if err != nil {
-		return models.ResourceCheck{"Yaeger", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "Yaeger", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"Yaeger", "No Yaeger pods found", false}
+		return models.ResourceCheck{Label: "Yaeger", Details: "No Yaeger pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Jaeger, Reason: "no pods found"}}}
 	}
 
 	// Check if Yaeger service is up
-	services, err := clientset.CoreV1().Services("fed-yaeger").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.Jaeger)
 	if err != nil {
-		return models.ResourceCheck{"Yaeger", "Error fetching services", false}
+		return models.ResourceCheck{Label: "Yaeger", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"Yaeger", "No Yaeger services found", false}
+		return models.ResourceCheck{Label: "Yaeger", Details: "No Yaeger services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Jaeger, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"Yaeger", "Yaeger is Up", true}
+	return models.ResourceCheck{Label: "Yaeger", Details: "Yaeger is Up", Outcome: models.OutcomePass}
 }
 
-func CheckElastic(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckElastic(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if Elastic pod is running in fed-elastic namespace
-	pods, err := clientset.CoreV1().Pods("fed-elastic").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.Elastic)
 	if err != nil {
-		return models.ResourceCheck{"Elastic", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "Elastic", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"Elastic", "No Elastic pods found", false}
+		return models.ResourceCheck{Label: "Elastic", Details: "No Elastic pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Elastic, Reason: "no pods found"}}}
 	}
 
 	// Check if Elastic service is up
-	services, err := clientset.CoreV1().Services("fed-elastic").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.Elastic)
 	if err != nil {
-		return models.ResourceCheck{"Elastic", "Error fetching services", false}
+		return models.ResourceCheck{Label: "Elastic", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"Elastic", "No Elastic services found", false}
+		return models.ResourceCheck{Label: "Elastic", Details: "No Elastic services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Elastic, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"Elastic", "Elastic is Up", true}
+	return models.ResourceCheck{Label: "Elastic", Details: "Elastic is Up", Outcome: models.OutcomePass}
 }
 
-func CheckElastAlert(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckElastAlert(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if ElastAlert pod is running in fed-elastalert namespace
-	pods, err := clientset.CoreV1().Pods("fed-elastalert").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.ElastAlert)
 	if err != nil {
-		return models.ResourceCheck{"ElastAlert", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "ElastAlert", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"ElastAlert", "No ElastAlert pods found", false}
+		return models.ResourceCheck{Label: "ElastAlert", Details: "No ElastAlert pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.ElastAlert, Reason: "no pods found"}}}
 	}
 
 	// Check if ElastAlert service is up
-	services, err := clientset.CoreV1().Services("fed-elastalert").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.ElastAlert)
 	if err != nil {
-		return models.ResourceCheck{"ElastAlert", "Error fetching services", false}
+		return models.ResourceCheck{Label: "ElastAlert", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"ElastAlert", "No ElastAlert services found", false}
+		return models.ResourceCheck{Label: "ElastAlert", Details: "No ElastAlert services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.ElastAlert, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"ElastAlert", "ElastAlert is Up", true}
+	return models.ResourceCheck{Label: "ElastAlert", Details: "ElastAlert is Up", Outcome: models.OutcomePass}
 }
 
-func CheckAlerta(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckAlerta(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if Alerta pod is running in fed-alerta namespace
-	pods, err := clientset.CoreV1().Pods("fed-alerta").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.Alerta)
 	if err != nil {
-		return models.ResourceCheck{"Alerta", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "Alerta", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"Alerta", "No Alerta pods found", false}
+		return models.ResourceCheck{Label: "Alerta", Details: "No Alerta pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Alerta, Reason: "no pods found"}}}
 	}
 
 	// Check if Alerta service is up
-	services, err := clientset.CoreV1().Services("fed-alerta").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.Alerta)
 	if err != nil {
-		return models.ResourceCheck{"Alerta", "Error fetching services", false}
+		return models.ResourceCheck{Label: "Alerta", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"Alerta", "No Alerta services found", false}
+		return models.ResourceCheck{Label: "Alerta", Details: "No Alerta services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Alerta, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"Alerta", "Alerta is Up", true}
+	return models.ResourceCheck{Label: "Alerta", Details: "Alerta is Up", Outcome: models.OutcomePass}
 }
 
-func CheckKiali(clientset *kubernetes.Clientset) models.ResourceCheck {
+func CheckKiali(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) models.ResourceCheck {
 
 	// Check if Kiali pod is running in fed-kiali namespace
-	pods, err := clientset.CoreV1().Pods("fed-kiali").List(context.Background(), metav1.ListOptions{})
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.Kiali)
 	if err != nil {
-		return models.ResourceCheck{"Kiali", "Error fetching pods", false}
+		return models.ResourceCheck{Label: "Kiali", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(pods.Items) == 0 {
-		return models.ResourceCheck{"Kiali", "No Kiali pods found", false}
+		return models.ResourceCheck{Label: "Kiali", Details: "No Kiali pods found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Kiali, Reason: "no pods found"}}}
 	}
 
 	// Check if Kiali service is up
-	services, err := clientset.CoreV1().Services("fed-kiali").List(context.Background(), metav1.ListOptions{})
+	services, err := snapshot.Services(ctx, cfg.Namespaces.Kiali)
 	if err != nil {
-		return models.ResourceCheck{"Kiali", "Error fetching services", false}
+		return models.ResourceCheck{Label: "Kiali", Details: "Error fetching services", Outcome: models.OutcomeError, Error: err}
 	}
 
 	if len(services.Items) == 0 {
-		return models.ResourceCheck{"Kiali", "No Kiali services found", false}
+		return models.ResourceCheck{Label: "Kiali", Details: "No Kiali services found", Outcome: models.OutcomeFail, Objects: []models.ObjectRef{{Kind: "Namespace", Name: cfg.Namespaces.Kiali, Reason: "no services found"}}}
 	}
 
-	return models.ResourceCheck{"Kiali", "Kiali is Up", true}
+	return models.ResourceCheck{Label: "Kiali", Details: "Kiali is Up", Outcome: models.OutcomePass}
 }
@@ -0,0 +1,248 @@
+package testsuite
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// podCause is the root cause an unhealthy pod is classified by
+type podCause string
+
+const (
+	causeCrashLoop     podCause = "CrashLoopBackOff"
+	causeOOMKilled     podCause = "OOMKilled"
+	causeImagePull     podCause = "ImagePull"
+	causeUnschedulable podCause = "Unschedulable"
+	causeInitContainer podCause = "InitContainer"
+	causeReadiness     podCause = "ReadinessProbe"
+	causeNotReady      podCause = "NotReady"
+	causeWaiting       podCause = "ContainerWaiting"
+	causeError         podCause = "Error"
+	causePending       podCause = "Pending"
+	causeFailed        podCause = "Failed"
+)
+
+// podStartGracePeriod is how long a new pod may be pending or not ready before it is reported
+const podStartGracePeriod = 2 * time.Minute
+
+// imagePullReasons are the waiting reasons of containers whose image cannot be pulled
+var imagePullReasons = map[string]bool{
+	"ImagePullBackOff":  true,
+	"ErrImagePull":      true,
+	"InvalidImageName":  true,
+	"ErrImageNeverPull": true,
+}
+
+// podDiagnosis is the root cause of an unhealthy pod and a one line explanation
+type podDiagnosis struct {
+	Cause  podCause
+	Reason string
+	// Warning is set for pods that recovered from the problem, such as a container
+	// that was OOM killed once and is ready again
+	Warning bool
+}
+
+// diagnosePod classifies why the pod is unhealthy, ok is false for healthy pods:
+// completed pods and running pods with all containers ready. Problems the pod
+// recovered from are only returned when nothing else is wrong with it.
+func diagnosePod(pod *v1.Pod, now time.Time) (diagnosis podDiagnosis, ok bool) {
+	if pod.Status.Phase == v1.PodSucceeded {
+		return podDiagnosis{}, false
+	}
+	started := pod.CreationTimestamp.Time
+	if pod.Status.StartTime != nil {
+		started = pod.Status.StartTime.Time
+	}
+	young := now.Sub(started) < podStartGracePeriod
+
+	// the containers tell the most specific cause, whatever the phase
+	var warning *podDiagnosis
+	healthy := func() (podDiagnosis, bool) {
+		if warning != nil {
+			return *warning, true
+		}
+		return podDiagnosis{}, false
+	}
+	for _, status := range pod.Status.InitContainerStatuses {
+		if d, ok := diagnoseContainer(status, "init container"); ok && !d.Warning {
+			return d, true
+		} else if ok && warning == nil {
+			warning = &d
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if d, ok := diagnoseContainer(status, "container"); ok && !d.Warning {
+			return d, true
+		} else if ok && warning == nil {
+			warning = &d
+		}
+	}
+
+	switch pod.Status.Phase {
+	case v1.PodFailed:
+		reason := pod.Status.Reason
+		if reason == "" {
+			reason = "Failed"
+		}
+		if pod.Status.Message != "" {
+			reason += ": " + pod.Status.Message
+		}
+		return podDiagnosis{Cause: causeFailed, Reason: reason}, true
+	case v1.PodPending:
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == v1.PodScheduled && condition.Status != v1.ConditionTrue && condition.Reason == v1.PodReasonUnschedulable {
+				return podDiagnosis{Cause: causeUnschedulable, Reason: "Unschedulable: " + condition.Message}, true
+			}
+		}
+		for _, status := range pod.Status.InitContainerStatuses {
+			if !status.Ready && !young {
+				return podDiagnosis{Cause: causeInitContainer, Reason: initContainerState(status)}, true
+			}
+		}
+		if young {
+			return healthy()
+		}
+		return podDiagnosis{Cause: causePending, Reason: pendingReason(pod)}, true
+	}
+
+	if young {
+		return healthy()
+	}
+	probes := map[string]bool{}
+	for _, container := range pod.Spec.Containers {
+		probes[container.Name] = container.ReadinessProbe != nil
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			continue
+		}
+		// waiting reasons not classified by diagnoseContainer, such as CreateContainerError
+		if waiting := status.State.Waiting; waiting != nil {
+			reason := fmt.Sprintf("container %s: waiting", status.Name)
+			if waiting.Reason != "" {
+				reason += ": " + waiting.Reason
+			}
+			if waiting.Message != "" {
+				reason += ", " + waiting.Message
+			}
+			return podDiagnosis{Cause: causeWaiting, Reason: reason}, true
+		}
+		if status.State.Running == nil {
+			continue
+		}
+		if probes[status.Name] {
+			return podDiagnosis{Cause: causeReadiness, Reason: fmt.Sprintf("container %s: readiness probe failing, %d restarts", status.Name, status.RestartCount)}, true
+		}
+		return podDiagnosis{Cause: causeNotReady, Reason: fmt.Sprintf("container %s: not ready, %d restarts", status.Name, status.RestartCount)}, true
+	}
+	if pod.Status.Phase != v1.PodRunning {
+		return podDiagnosis{Cause: causePending, Reason: string(pod.Status.Phase)}, true
+	}
+	return healthy()
+}
+
+// diagnoseContainer classifies containers that crash, were OOM killed or cannot pull
+// their image. A container that was OOM killed before but is ready again, or an init
+// container that completed since, is only a warning. Other states are left to the
+// pod level checks.
+func diagnoseContainer(status v1.ContainerStatus, kind string) (podDiagnosis, bool) {
+	name := fmt.Sprintf("%s %s", kind, status.Name)
+	last := status.LastTerminationState.Terminated
+	if terminated := status.State.Terminated; terminated != nil && terminated.Reason == "OOMKilled" {
+		last = terminated
+	}
+	if waiting := status.State.Waiting; waiting != nil {
+		switch {
+		case imagePullReasons[waiting.Reason]:
+			reason := fmt.Sprintf("%s: %s, image %s", name, waiting.Reason, status.Image)
+			if waiting.Message != "" {
+				reason += ": " + waiting.Message
+			}
+			return podDiagnosis{Cause: causeImagePull, Reason: reason}, true
+		case waiting.Reason == "CrashLoopBackOff":
+			cause := causeCrashLoop
+			if last != nil && last.Reason == "OOMKilled" {
+				cause = causeOOMKilled
+			}
+			return podDiagnosis{Cause: cause, Reason: fmt.Sprintf("%s: CrashLoopBackOff, %d restarts%s", name, status.RestartCount, lastTermination(last))}, true
+		}
+	}
+	if last != nil && last.Reason == "OOMKilled" {
+		diagnosis := podDiagnosis{Cause: causeOOMKilled, Reason: fmt.Sprintf("%s: OOMKilled, %d restarts%s", name, status.RestartCount, lastTermination(last))}
+		completed := status.State.Terminated != nil && status.State.Terminated.ExitCode == 0
+		if last != status.State.Terminated && (status.Ready || completed) {
+			diagnosis.Warning = true
+			diagnosis.Reason += ", recovered"
+		}
+		return diagnosis, true
+	}
+	if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+		return podDiagnosis{Cause: causeError, Reason: fmt.Sprintf("%s: %s (exit code %d)", name, terminated.Reason, terminated.ExitCode)}, true
+	}
+	return podDiagnosis{}, false
+}
+
+// lastTermination describes the last termination of a restarted container
+func lastTermination(last *v1.ContainerStateTerminated) string {
+	if last == nil {
+		return ""
+	}
+	if last.FinishedAt.IsZero() {
+		return fmt.Sprintf(", last terminated %s (exit code %d)", last.Reason, last.ExitCode)
+	}
+	return fmt.Sprintf(", last terminated %s (exit code %d) at %s", last.Reason, last.ExitCode, last.FinishedAt.Format(time.RFC3339))
+}
+
+// initContainerState describes an init container that has not completed
+func initContainerState(status v1.ContainerStatus) string {
+	name := "init container " + status.Name
+	switch {
+	case status.State.Running != nil:
+		return fmt.Sprintf("%s: running since %s", name, status.State.Running.StartedAt.Format(time.RFC3339))
+	case status.State.Waiting != nil:
+		return strings.TrimSpace(fmt.Sprintf("%s: waiting, %s %s", name, status.State.Waiting.Reason, status.State.Waiting.Message))
+	case status.State.Terminated != nil:
+		return fmt.Sprintf("%s: %s (exit code %d)", name, status.State.Terminated.Reason, status.State.Terminated.ExitCode)
+	}
+	return name + ": not started"
+}
+
+// pendingReason explains a scheduled pod whose containers have not started
+func pendingReason(pod *v1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason != "" {
+			return strings.TrimSpace(fmt.Sprintf("container %s: %s %s", status.Name, status.State.Waiting.Reason, status.State.Waiting.Message))
+		}
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Status != v1.ConditionTrue && condition.Message != "" {
+			return fmt.Sprintf("%s: %s", condition.Reason, condition.Message)
+		}
+	}
+	return "Pending"
+}
+
+// podWorkload names the workload owning the pod as Kind/name, following replica sets
+// to their deployment. Pods without an owner are their own workload.
+func podWorkload(pod *v1.Pod, replicaSets map[string]*appsv1.ReplicaSet) string {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Controller == nil || !*owner.Controller {
+			continue
+		}
+		if owner.Kind == "ReplicaSet" {
+			if rs, ok := replicaSets[pod.Namespace+"/"+owner.Name]; ok {
+				for _, rsOwner := range rs.OwnerReferences {
+					if rsOwner.Controller != nil && *rsOwner.Controller {
+						return rsOwner.Kind + "/" + rsOwner.Name
+					}
+				}
+			}
+		}
+		return owner.Kind + "/" + owner.Name
+	}
+	return "Pod/" + pod.Name
+}
@@ -0,0 +1,145 @@
+package testsuite
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var podNow = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// testPod returns a pod of the given phase started an hour before podNow
+func testPod(phase v1.PodPhase, init []v1.ContainerStatus, containers ...v1.ContainerStatus) *v1.Pod {
+	started := metav1.NewTime(podNow.Add(-time.Hour))
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-0", Namespace: "default", CreationTimestamp: started},
+		Status: v1.PodStatus{
+			Phase:                 phase,
+			StartTime:             &started,
+			InitContainerStatuses: init,
+			ContainerStatuses:     containers,
+		},
+	}
+	for _, status := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: status.Name})
+	}
+	return pod
+}
+
+func running(name string, ready bool, restarts int32) v1.ContainerStatus {
+	return v1.ContainerStatus{
+		Name:         name,
+		Ready:        ready,
+		RestartCount: restarts,
+		State:        v1.ContainerState{Running: &v1.ContainerStateRunning{StartedAt: metav1.NewTime(podNow.Add(-time.Minute))}},
+	}
+}
+
+func waiting(name, reason string) v1.ContainerStatus {
+	return v1.ContainerStatus{Name: name, Image: "registry/app:1", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: reason}}}
+}
+
+func oomKilled() *v1.ContainerStateTerminated {
+	return &v1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137, FinishedAt: metav1.NewTime(podNow.Add(-48 * time.Hour))}
+}
+
+func TestDiagnosePod(t *testing.T) {
+	recoveredOOM := running("app", true, 1)
+	recoveredOOM.LastTerminationState.Terminated = oomKilled()
+	notReadyOOM := running("app", false, 1)
+	notReadyOOM.LastTerminationState.Terminated = oomKilled()
+	crashLoopOOM := waiting("app", "CrashLoopBackOff")
+	crashLoopOOM.RestartCount = 5
+	crashLoopOOM.LastTerminationState.Terminated = oomKilled()
+	terminatedOOM := v1.ContainerStatus{Name: "app", State: v1.ContainerState{Terminated: oomKilled()}}
+	completedInit := v1.ContainerStatus{Name: "migrate", Ready: true, State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Completed"}}}
+	completedInit.LastTerminationState.Terminated = oomKilled()
+	stuckInit := running("migrate", false, 0)
+	crashingInit := waiting("migrate", "CrashLoopBackOff")
+	crashingInit.LastTerminationState.Terminated = &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}
+
+	unschedulable := testPod(v1.PodPending, nil)
+	unschedulable.Status.Conditions = []v1.PodCondition{{
+		Type:    v1.PodScheduled,
+		Status:  v1.ConditionFalse,
+		Reason:  v1.PodReasonUnschedulable,
+		Message: "0/3 nodes are available: 3 Insufficient memory.",
+	}}
+	youngPending := testPod(v1.PodPending, []v1.ContainerStatus{stuckInit}, waiting("app", "PodInitializing"))
+	youngStart := metav1.NewTime(podNow.Add(-time.Minute))
+	youngPending.Status.StartTime = &youngStart
+
+	tests := []struct {
+		name    string
+		pod     *v1.Pod
+		ok      bool
+		cause   podCause
+		warning bool
+	}{
+		{name: "running and ready", pod: testPod(v1.PodRunning, nil, running("app", true, 0))},
+		{name: "completed", pod: testPod(v1.PodSucceeded, nil, v1.ContainerStatus{Name: "job"})},
+		{name: "crash loop", pod: testPod(v1.PodRunning, nil, waiting("app", "CrashLoopBackOff")), ok: true, cause: causeCrashLoop},
+		{name: "crash loop after OOM kill", pod: testPod(v1.PodRunning, nil, crashLoopOOM), ok: true, cause: causeOOMKilled},
+		{name: "terminated by OOM kill", pod: testPod(v1.PodRunning, nil, terminatedOOM), ok: true, cause: causeOOMKilled},
+		{name: "OOM killed and not ready", pod: testPod(v1.PodRunning, nil, notReadyOOM), ok: true, cause: causeOOMKilled},
+		{name: "OOM killed once and ready again", pod: testPod(v1.PodRunning, nil, recoveredOOM), ok: true, cause: causeOOMKilled, warning: true},
+		{name: "OOM killed init container completed", pod: testPod(v1.PodRunning, []v1.ContainerStatus{completedInit}, running("app", true, 0)), ok: true, cause: causeOOMKilled, warning: true},
+		{name: "recovered OOM kill next to a crash loop", pod: testPod(v1.PodRunning, nil, recoveredOOM, waiting("sidecar", "CrashLoopBackOff")), ok: true, cause: causeCrashLoop},
+		{name: "image pull back off", pod: testPod(v1.PodPending, nil, waiting("app", "ImagePullBackOff")), ok: true, cause: causeImagePull},
+		{name: "invalid image name", pod: testPod(v1.PodPending, nil, waiting("app", "InvalidImageName")), ok: true, cause: causeImagePull},
+		{name: "unschedulable", pod: unschedulable, ok: true, cause: causeUnschedulable},
+		{name: "stuck init container", pod: testPod(v1.PodPending, []v1.ContainerStatus{stuckInit}, waiting("app", "PodInitializing")), ok: true, cause: causeInitContainer},
+		{name: "crashing init container", pod: testPod(v1.PodPending, []v1.ContainerStatus{crashingInit}, waiting("app", "PodInitializing")), ok: true, cause: causeCrashLoop},
+		{name: "young pod initializing", pod: youngPending},
+		{name: "container waiting for another reason", pod: testPod(v1.PodRunning, nil, waiting("app", "CreateContainerConfigError")), ok: true, cause: causeWaiting},
+		{name: "running but not ready", pod: testPod(v1.PodRunning, nil, running("app", false, 2)), ok: true, cause: causeNotReady},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnosis, ok := diagnosePod(tt.pod, podNow)
+			if ok != tt.ok {
+				t.Fatalf("diagnosePod() ok = %v, want %v (%+v)", ok, tt.ok, diagnosis)
+			}
+			if diagnosis.Cause != tt.cause || diagnosis.Warning != tt.warning {
+				t.Errorf("diagnosePod() = %+v, want cause %s, warning %v", diagnosis, tt.cause, tt.warning)
+			}
+		})
+	}
+}
+
+func TestDiagnoseContainer(t *testing.T) {
+	recovered := running("app", true, 3)
+	recovered.LastTerminationState.Terminated = oomKilled()
+	crashed := running("app", true, 3)
+	crashed.LastTerminationState.Terminated = &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}
+	failed := v1.ContainerStatus{Name: "app", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 2}}}
+
+	tests := []struct {
+		name    string
+		status  v1.ContainerStatus
+		ok      bool
+		reason  string
+		warning bool
+	}{
+		{name: "running", status: running("app", true, 0)},
+		{name: "restarted after an error", status: crashed},
+		{name: "OOM killed and recovered", status: recovered, ok: true, warning: true,
+			reason: "container app: OOMKilled, 3 restarts, last terminated OOMKilled (exit code 137) at 2026-09-29T12:00:00Z, recovered"},
+		{name: "image pull", status: waiting("app", "ErrImagePull"), ok: true, reason: "container app: ErrImagePull, image registry/app:1"},
+		{name: "terminated with an error", status: failed, ok: true, reason: "container app: Error (exit code 2)"},
+		{name: "waiting to be created", status: waiting("app", "ContainerCreating")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnosis, ok := diagnoseContainer(tt.status, "container")
+			if ok != tt.ok {
+				t.Fatalf("diagnoseContainer() ok = %v, want %v (%+v)", ok, tt.ok, diagnosis)
+			}
+			if diagnosis.Reason != tt.reason || diagnosis.Warning != tt.warning {
+				t.Errorf("diagnoseContainer() = %+v, want reason %q, warning %v", diagnosis, tt.reason, tt.warning)
+			}
+		})
+	}
+}
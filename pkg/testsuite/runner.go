@@ -0,0 +1,140 @@
+package testsuite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"healthctl/pkg/models"
+)
+
+// RunOptions controls how checks are executed
+type RunOptions struct {
+	// Concurrency is the number of checks running at the same time
+	Concurrency int
+	// Timeout bounds the run time of every single check
+	Timeout time.Duration
+}
+
+// DefaultRunOptions fill in the fields of RunOptions that are left zero
+var DefaultRunOptions = RunOptions{
+	Concurrency: 4,
+	Timeout:     30 * time.Second,
+}
+
+func (o RunOptions) withDefaults() RunOptions {
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultRunOptions.Concurrency
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultRunOptions.Timeout
+	}
+	return o
+}
+
+// RunChecks runs the checks on a bounded pool of workers and returns all results
+// in the order of the checks, with their duration and severity filled in.
+// Checks exceeding the timeout are reported as errors; once ctx is cancelled the
+// checks still running or waiting are reported as skipped.
+func RunChecks(ctx context.Context, clients *Clients, checks []Check, opts RunOptions) []models.ResourceCheck {
+	opts = opts.withDefaults()
+	workers := min(opts.Concurrency, len(checks))
+
+	checkResults := make([][]models.ResourceCheck, len(checks))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				checkResults[i] = runCheck(ctx, clients, checks[i], opts.Timeout)
+			}
+		}()
+	}
+	for i := range checks {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	results := []models.ResourceCheck{}
+	for _, checkResult := range checkResults {
+		results = append(results, checkResult...)
+	}
+	return results
+}
+
+// runCheck runs a single check with its own timeout. The check keeps running in
+// the background if it ignores its context, but its results are discarded.
+func runCheck(ctx context.Context, clients *Clients, check Check, timeout time.Duration) []models.ResourceCheck {
+	if ctx.Err() != nil {
+		return []models.ResourceCheck{cancelledResult(check)}
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan []models.ResourceCheck, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- []models.ResourceCheck{{
+					Label:   check.Name(),
+					Details: fmt.Sprintf("Check %s/%s panicked", check.Suite(), check.Name()),
+					Outcome: models.OutcomeError,
+					Error:   fmt.Errorf("%v", r),
+				}}
+			}
+		}()
+		done <- check.Run(checkCtx, clients)
+	}()
+
+	var results []models.ResourceCheck
+	select {
+	case results = <-done:
+	case <-checkCtx.Done():
+	}
+	elapsed := time.Since(start)
+
+	switch {
+	case ctx.Err() != nil:
+		results = []models.ResourceCheck{cancelledResult(check)}
+	case checkCtx.Err() != nil:
+		results = []models.ResourceCheck{{
+			Label:   check.Name(),
+			Details: fmt.Sprintf("Check %s/%s timed out after %s", check.Suite(), check.Name(), timeout),
+			Outcome: models.OutcomeError,
+			Error:   checkCtx.Err(),
+		}}
+	}
+
+	for i := range results {
+		results[i].Check = check.Suite() + "/" + check.Name()
+		if results[i].Duration == 0 {
+			results[i].Duration = elapsed
+		}
+		if results[i].Severity == "" {
+			results[i].Severity = models.DefaultSeverity(results[i].Outcome)
+		}
+	}
+	return results
+}
+
+func cancelledResult(check Check) models.ResourceCheck {
+	return models.ResourceCheck{
+		Label:   check.Name(),
+		Details: fmt.Sprintf("Check %s/%s was cancelled", check.Suite(), check.Name()),
+		Outcome: models.OutcomeSkipped,
+	}
+}
+
+// RunSuite runs every check of the named suite
+func RunSuite(ctx context.Context, clients *Clients, name string, opts RunOptions) ([]models.ResourceCheck, error) {
+	if _, ok := LookupSuite(name); !ok {
+		return nil, fmt.Errorf("unknown suite %q, valid suites are: %s", name, strings.Join(SuiteNames(), ", "))
+	}
+	return RunChecks(ctx, clients, Checks(Filter{Suites: []string{name}}), opts), nil
+}
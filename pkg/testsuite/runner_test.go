@@ -0,0 +1,125 @@
+package testsuite
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"healthctl/pkg/models"
+)
+
+func passing(ctx context.Context, clients *Clients) []models.ResourceCheck {
+	return []models.ResourceCheck{{Label: "ok", Outcome: models.OutcomePass}}
+}
+
+func panicking(ctx context.Context, clients *Clients) []models.ResourceCheck {
+	panic("nil map")
+}
+
+// blocking returns a check that waits for its context, or for release when it ignores it
+func blocking(release <-chan struct{}, honourCtx bool) RunFunc {
+	return func(ctx context.Context, clients *Clients) []models.ResourceCheck {
+		if honourCtx {
+			select {
+			case <-ctx.Done():
+			case <-release:
+			}
+		} else {
+			<-release
+		}
+		return []models.ResourceCheck{{Label: "late", Outcome: models.OutcomePass}}
+	}
+}
+
+func TestRunChecks(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	checks := []Check{
+		NewCheck("test", "pass", "", nil, passing),
+		NewCheck("test", "panic", "", nil, panicking),
+		NewCheck("test", "timeout", "", nil, blocking(release, true)),
+		NewCheck("test", "stuck", "", nil, blocking(release, false)),
+	}
+	start := time.Now()
+	results := RunChecks(context.Background(), nil, checks, RunOptions{Concurrency: 2, Timeout: 50 * time.Millisecond})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("RunChecks() took %s, want it bounded by the check timeout", elapsed)
+	}
+
+	want := []struct {
+		check   string
+		outcome models.Outcome
+	}{
+		{"test/pass", models.OutcomePass},
+		{"test/panic", models.OutcomeError},
+		{"test/timeout", models.OutcomeError},
+		{"test/stuck", models.OutcomeError},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("RunChecks() returned %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.Check != w.check || got.Outcome != w.outcome {
+			t.Errorf("result %d = %s %s, want %s %s", i, got.Check, got.Outcome, w.check, w.outcome)
+		}
+		if got.Severity != models.DefaultSeverity(w.outcome) {
+			t.Errorf("result %d severity = %s, want %s", i, got.Severity, models.DefaultSeverity(w.outcome))
+		}
+		if got.Duration <= 0 {
+			t.Errorf("result %d has no duration", i)
+		}
+	}
+	if results[1].Error == nil || results[1].Error.Error() != "nil map" {
+		t.Errorf("panic result error = %v, want the panic value", results[1].Error)
+	}
+	for _, i := range []int{2, 3} {
+		if !errors.Is(results[i].Error, context.DeadlineExceeded) {
+			t.Errorf("%s error = %v, want %v", results[i].Check, results[i].Error, context.DeadlineExceeded)
+		}
+	}
+}
+
+func TestRunChecksCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var started atomic.Int32
+	running := make(chan struct{})
+	first := func(ctx context.Context, clients *Clients) []models.ResourceCheck {
+		started.Add(1)
+		close(running)
+		<-ctx.Done()
+		return []models.ResourceCheck{{Label: "first", Outcome: models.OutcomePass}}
+	}
+	pending := func(ctx context.Context, clients *Clients) []models.ResourceCheck {
+		started.Add(1)
+		return passing(ctx, clients)
+	}
+	go func() {
+		<-running
+		cancel()
+	}()
+
+	checks := []Check{
+		NewCheck("test", "first", "", nil, first),
+		NewCheck("test", "second", "", nil, pending),
+		NewCheck("test", "third", "", nil, pending),
+	}
+	results := RunChecks(ctx, nil, checks, RunOptions{Concurrency: 1, Timeout: time.Minute})
+
+	if n := started.Load(); n != 1 {
+		t.Errorf("%d checks started, want only the first one", n)
+	}
+	if len(results) != len(checks) {
+		t.Fatalf("RunChecks() returned %d results, want %d: %+v", len(results), len(checks), results)
+	}
+	for i, result := range results {
+		if result.Outcome != models.OutcomeSkipped {
+			t.Errorf("result %d = %s %s, want %s", i, result.Check, result.Outcome, models.OutcomeSkipped)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package testsuite
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// readyEndpoints counts the ready endpoints of the service across its endpoint slices.
+// Dual-stack services have one slice per address family, so endpoints are counted
+// once per target pod.
+func readyEndpoints(service *v1.Service, slices []discoveryv1.EndpointSlice) int {
+	ready := map[string]bool{}
+	for _, slice := range slices {
+		if slice.Namespace != service.Namespace || slice.Labels[discoveryv1.LabelServiceName] != service.Name {
+			continue
+		}
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			switch {
+			case endpoint.TargetRef != nil:
+				ready[endpoint.TargetRef.Kind+"/"+endpoint.TargetRef.Name] = true
+			case len(endpoint.Addresses) > 0:
+				ready[endpoint.Addresses[0]] = true
+			}
+		}
+	}
+	return len(ready)
+}
+
+// servingPods counts the running pods of the namespace the service selects, leaving
+// out pods being deleted
+func servingPods(service *v1.Service, pods []v1.Pod) int {
+	selector := labels.SelectorFromSet(service.Spec.Selector)
+	count := 0
+	for _, pod := range pods {
+		if pod.Namespace == service.Namespace && pod.DeletionTimestamp == nil && pod.Status.Phase == v1.PodRunning && selector.Matches(labels.Set(pod.Labels)) {
+			count++
+		}
+	}
+	return count
+}
+
+// serviceProblem describes what is wrong with the service, joining every problem
+// found; failed is false when it still serves traffic from some of its pods
+func serviceProblem(service *v1.Service, slices []discoveryv1.EndpointSlice, pods []v1.Pod) (problem string, failed bool) {
+	problems := []string{}
+	if service.Spec.Type == v1.ServiceTypeLoadBalancer && len(service.Status.LoadBalancer.Ingress) == 0 {
+		problems = append(problems, "LoadBalancer waiting on an external IP")
+		failed = true
+	}
+	if len(service.Spec.Selector) > 0 {
+		ready := readyEndpoints(service, slices)
+		matching := servingPods(service, pods)
+		switch {
+		case ready == 0 && matching == 0:
+			problems = append(problems, "no ready endpoints, the selector matches no running pod")
+			failed = true
+		case ready == 0:
+			problems = append(problems, fmt.Sprintf("no ready endpoints for %d running pods", matching))
+			failed = true
+		case ready < matching:
+			problems = append(problems, fmt.Sprintf("only %d of %d running pods are ready endpoints", ready, matching))
+		}
+	}
+	return strings.Join(problems, "; "), failed
+}
@@ -3,35 +3,35 @@ package testsuite
 import (
 	"context"
 	"fmt"
-	"healthctl/pkg/models"
-	"os/exec"
 	"strings"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
+	"healthctl/pkg/config"
+	"healthctl/pkg/k8s"
+	"healthctl/pkg/models"
 )
 
-func CheckSMF(clientset *kubernetes.Clientset) []models.ResourceCheck {
-	checks := []models.ResourceCheck{}
-	checks = append(checks, CheckPods(clientset)...)
-	checks = append(checks, CheckSMFMonitor(clientset)...)
-	return checks
+func init() {
+	Register(
+		NewCheck(SuiteSMF, "pods", "All containers of every SMF deployment are ready", []string{"workloads", "smf"}, configChecks(CheckPods)),
+		NewCheck(SuiteSMF, "monitor", "Critical services reported by smfmonitor are UP", []string{"monitor", "smf"}, CheckSMFMonitor),
+	)
 }
 
 // Check functions
-func CheckPods(clientset *kubernetes.Clientset) []models.ResourceCheck {
-	ctx := context.TODO()
-	pods, err := clientset.CoreV1().Pods("fed-smf").List(ctx, metav1.ListOptions{})
+func CheckPods(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) []models.ResourceCheck {
+	pods, err := snapshot.Pods(ctx, cfg.Namespaces.SMF)
 	if err != nil {
-		return append([]models.ResourceCheck{}, models.ResourceCheck{
+		return []models.ResourceCheck{{
 			Label:   "Pods",
 			Details: "Failed to list pods in SMF namespace",
-			Status:  false,
-		})
+			Outcome: models.OutcomeError,
+			Error:   err,
+		}}
 	}
 
 	deploymentStatus := make(map[string]bool)
 	deploymentDetails := make(map[string][]string)
+	deploymentObjects := make(map[string][]models.ObjectRef)
 
 	for _, pod := range pods.Items {
 		deploymentName := pod.Labels["app"]
@@ -50,6 +50,7 @@ func CheckPods(clientset *kubernetes.Clientset) []models.ResourceCheck {
 
 		if !allContainersReady {
 			deploymentStatus[deploymentName] = false
+			deploymentObjects[deploymentName] = append(deploymentObjects[deploymentName], models.ObjectRef{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name, Reason: podFailureReason(&pod)})
 		}
 
 		deploymentDetails[deploymentName] = append(deploymentDetails[deploymentName], pod.Name)
@@ -60,66 +61,18 @@ func CheckPods(clientset *kubernetes.Clientset) []models.ResourceCheck {
 		checks = append(checks, models.ResourceCheck{
 			Label:   deployment,
 			Details: fmt.Sprintf("Deployment: %s", strings.Join(deploymentDetails[deployment], ", ")),
-			Status:  status,
+			Outcome: models.OutcomeFor(status),
+			Objects: deploymentObjects[deployment],
 		})
 	}
 
 	return checks
 }
 
-func CheckSMFMonitor(clientset *kubernetes.Clientset) []models.ResourceCheck {
-	ctx := context.TODO()
-	pods, err := clientset.CoreV1().Pods("fed-smf").List(ctx, metav1.ListOptions{
-		LabelSelector: "app=smfmonitor-app",
-	})
-	if err != nil || len(pods.Items) == 0 {
-		return append([]models.ResourceCheck{}, models.ResourceCheck{
-			Label:   "SMF Monitor",
-			Details: "Failed to find smf-monitor pod",
-			Status:  false,
-		})
-	}
-	podName := pods.Items[0].Name
-	cmd := []string{
-		"kubectl",
-		"exec",
-		"-n", "fed-smf",
-		podName,
-		"-c", "smfmonitor",
-		"--",
-		"sh",
-		"-c",
-		"curl -s http://127.0.0.1:9090/tenv/SmfMonitorCliIf/smfmonitor-info | grep -A26 'Critical Ready Services Monitoring Status' ",
-	}
-
-	output, _ := exec.Command(cmd[0], cmd[1:]...).CombinedOutput()
-	var checks []models.ResourceCheck
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "ServiceName:") {
-			parts := strings.Split(line, "|")
-			serviceName := strings.TrimSpace(strings.Split(parts[0], ":")[1])
-			currentInstances := ""
-			minInstances := ""
-			status := ""
-			for _, part := range parts {
-				if strings.Contains(part, "Current No Of Instances") || strings.Contains(part, "Current Available Servers Count") {
-					currentInstances = strings.TrimSpace(strings.Split(part, ":")[2])
-				}
-				if strings.Contains(part, "Minimum No Of Instances Required") || strings.Contains(part, "Minimum No Of Clusters Required") || strings.Contains(part, "Minimum No Of Servers Required") {
-					minInstances = strings.TrimSpace(strings.Split(part, ":")[1])
-					minInstances = strings.Split(minInstances, ",")[0]
-				}
-				if strings.Contains(part, "Status") {
-					status = strings.TrimSpace(strings.Split(part, ":")[1])
-				}
-			}
-			checks = append(checks, models.ResourceCheck{
-				Label:   serviceName,
-				Details: fmt.Sprintf("Service %s: Avl: %s, Min Req: %s ", serviceName, currentInstances, minInstances),
-				Status:  status == "UP",
-			})
-		}
-	}
-	return checks
+// CheckSMFMonitor reads the critical services from the smfmonitor-info endpoint,
+// querying it from inside the smfmonitor pod with the clients of the run
+func CheckSMFMonitor(ctx context.Context, clients *Clients) []models.ResourceCheck {
+	monitor := clients.Config.SMFMonitor
+	url := fmt.Sprintf("http://127.0.0.1:%d/tenv/SmfMonitorCliIf/smfmonitor-info", monitor.Port)
+	return queryMonitor(ctx, clients, "SMF Monitor", monitor.Namespace, monitor.Selector, monitor.Container, url)
 }
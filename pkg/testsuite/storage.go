@@ -1,14 +1,420 @@
 package testsuite
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"healthctl/pkg/config"
+	"healthctl/pkg/k8s"
 	"healthctl/pkg/models"
 
-	"k8s.io/client-go/kubernetes"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// pendingClaimGracePeriod is how long a claim may stay Pending while it is provisioned
+const pendingClaimGracePeriod = 5 * time.Minute
+
+// Annotations marking the default storage class
+const (
+	defaultClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+)
+
+func init() {
+	Register(
+		NewCheck(SuiteStorage, "ceph-health", "Every CephCluster reports HEALTH_OK", []string{"storage", "ceph"}, CheckCephHealth),
+		NewCheck(SuiteStorage, "ceph-daemons", "All Ceph OSD and mon pods are ready", []string{"storage", "ceph"}, configChecks(CheckCephDaemons)),
+		NewCheck(SuiteStorage, "storage-classes", "Storage classes exist and exactly one is the default", []string{"storage"}, snapshotCheck(checkStorageClasses)),
+		NewCheck(SuiteStorage, "pending-claims", "No persistent volume claim is stuck in Pending", []string{"storage"}, snapshotCheck(checkPendingClaims)),
+		NewCheck(SuiteStorage, "volume-usage", "Mounted volumes are below the usage thresholds", []string{"storage"}, CheckVolumeUsage),
+		NewCheck(SuiteStorage, "data-stores", "Stateful sets of the data stores are ready and their claims bound", []string{"storage", "workloads"}, configChecks(CheckDataStores)),
+	)
+}
+
+// CheckCephHealth reports the Ceph health of every CephCluster managed by Rook
+func CheckCephHealth(ctx context.Context, clients *Clients) []models.ResourceCheck {
+	ceph := clients.Config.Storage.Ceph
+	clusters, err := clients.GetCephClusters(ctx)
+	if apierrors.IsNotFound(err) {
+		return []models.ResourceCheck{{
+			Label:   "Ceph",
+			Details: fmt.Sprintf("%s.%s is not installed, Rook/Ceph is not deployed", ceph.Resource, ceph.Group),
+			Outcome: models.OutcomeSkipped,
+		}}
+	}
+	if err != nil {
+		return []models.ResourceCheck{{Label: "Ceph", Details: "Error fetching CephClusters", Outcome: models.OutcomeError, Error: err}}
+	}
+	if len(clusters) == 0 {
+		return []models.ResourceCheck{{
+			Label:   "Ceph",
+			Details: fmt.Sprintf("No CephCluster found in %s", ceph.Namespace),
+			Outcome: models.OutcomeSkipped,
+		}}
+	}
+
+	var checks []models.ResourceCheck
+	for _, cluster := range clusters {
+		outcome := models.OutcomeFail
+		switch cluster.Health {
+		case "HEALTH_OK":
+			outcome = models.OutcomePass
+		case "HEALTH_WARN":
+			outcome = models.OutcomeWarn
+		}
+		health := cluster.Health
+		if health == "" {
+			health = "health unknown"
+		}
+		details := fmt.Sprintf("CephCluster %s: %s, phase %s", cluster.Name, health, cluster.Phase)
+		if len(cluster.Messages) > 0 {
+			sort.Strings(cluster.Messages)
+			details += "; " + strings.Join(cluster.Messages, "; ")
+		}
+		check := models.ResourceCheck{Label: "Ceph " + cluster.Name, Details: details, Outcome: outcome}
+		if outcome != models.OutcomePass {
+			check.Objects = []models.ObjectRef{{Kind: "CephCluster", Namespace: cluster.Namespace, Name: cluster.Name, Reason: health}}
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// CheckCephDaemons reports the readiness of the Ceph OSD and mon pods
+func CheckCephDaemons(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) []models.ResourceCheck {
+	ceph := cfg.Storage.Ceph
+	all, err := snapshot.Pods(ctx, ceph.Namespace)
+	if err != nil {
+		return []models.ResourceCheck{{Label: "Ceph daemons", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}}
+	}
+	if len(all.Items) == 0 {
+		return []models.ResourceCheck{{
+			Label:   "Ceph daemons",
+			Details: fmt.Sprintf("No pods in %s, Rook/Ceph is not deployed", ceph.Namespace),
+			Outcome: models.OutcomeSkipped,
+		}}
+	}
+
+	var checks []models.ResourceCheck
+	for _, daemon := range []struct{ label, selector string }{{"Ceph OSDs", ceph.OSDSelector}, {"Ceph mons", ceph.MonSelector}} {
+		pods, err := snapshot.PodsWithLabels(ctx, ceph.Namespace, daemon.selector)
+		if err != nil {
+			checks = append(checks, models.ResourceCheck{Label: daemon.label, Details: fmt.Sprintf("Error fetching %s pods", daemon.selector), Outcome: models.OutcomeError, Error: err})
+			continue
+		}
+		notReady := []models.ObjectRef{}
+		for _, pod := range pods.Items {
+			if !podReady(&pod) {
+				notReady = append(notReady, models.ObjectRef{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name, Reason: podFailureReason(&pod)})
+			}
+		}
+		total := len(pods.Items)
+		details := fmt.Sprintf("Ready: %d/%d %s pods", total-len(notReady), total, daemon.selector)
+		checks = append(checks, models.ResourceCheck{
+			Label:   daemon.label,
+			Details: details,
+			Outcome: models.OutcomeFor(total > 0 && len(notReady) == 0),
+			Objects: notReady,
+		})
+	}
+	return checks
+}
+
+// podReady reports whether the Ready condition of the pod is true
+func podReady(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// isDefaultClass reports whether the storage class is marked as the default
+func isDefaultClass(class *storagev1.StorageClass) bool {
+	return class.Annotations[defaultClassAnnotation] == "true" || class.Annotations[betaDefaultClassAnnotation] == "true"
+}
+
+func checkStorageClasses(ctx context.Context, snapshot *k8s.Snapshot) models.ResourceCheck {
+	classes, err := snapshot.StorageClasses(ctx)
+	if err != nil {
+		return models.ResourceCheck{Label: "Storage Classes", Details: "Error fetching storage classes", Outcome: models.OutcomeError, Error: err}
+	}
+	if len(classes.Items) == 0 {
+		return models.ResourceCheck{Label: "Storage Classes", Details: "No storage classes are available.", Outcome: models.OutcomeFail}
+	}
+
+	defaults := []models.ObjectRef{}
+	for _, class := range classes.Items {
+		if isDefaultClass(&class) {
+			defaults = append(defaults, models.ObjectRef{Kind: "StorageClass", Name: class.Name, Reason: "marked as default"})
+		}
+	}
+	switch len(defaults) {
+	case 0:
+		return models.ResourceCheck{Label: "Storage Classes", Details: fmt.Sprintf("%d storage classes, none is the default", len(classes.Items)), Outcome: models.OutcomeWarn}
+	case 1:
+		return models.ResourceCheck{Label: "Storage Classes", Details: fmt.Sprintf("%d storage classes, default: %s", len(classes.Items), defaults[0].Name), Outcome: models.OutcomePass}
+	}
+	return models.ResourceCheck{
+		Label:   "Storage Classes",
+		Details: fmt.Sprintf("%d storage classes are marked as default", len(defaults)),
+		Outcome: models.OutcomeWarn,
+		Objects: defaults,
+	}
+}
+
+func checkPendingClaims(ctx context.Context, snapshot *k8s.Snapshot) models.ResourceCheck {
+	pvcs, err := snapshot.PersistentVolumeClaims(ctx, "")
+	if err != nil {
+		return models.ResourceCheck{Label: "Pending Claims", Details: "Error fetching persistent volume claims", Outcome: models.OutcomeError, Error: err}
+	}
+	classes, err := snapshot.StorageClasses(ctx)
+	if err != nil {
+		return models.ResourceCheck{Label: "Pending Claims", Details: "Error fetching storage classes", Outcome: models.OutcomeError, Error: err}
+	}
+	pods, err := snapshot.Pods(ctx, "")
+	if err != nil {
+		return models.ResourceCheck{Label: "Pending Claims", Details: "Error fetching pods", Outcome: models.OutcomeError, Error: err}
+	}
+
+	// claims of WaitForFirstConsumer classes stay Pending until a pod uses them
+	waitForConsumer := map[string]bool{}
+	for _, class := range classes.Items {
+		if class.VolumeBindingMode != nil && *class.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer {
+			waitForConsumer[class.Name] = true
+		}
+	}
+	consumed := map[string]bool{}
+	for _, pod := range pods.Items {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				consumed[pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName] = true
+			}
+		}
+	}
+
+	stuck := []models.ObjectRef{}
+	for _, pvc := range pvcs.Items {
+		if pvc.Status.Phase != v1.ClaimPending {
+			continue
+		}
+		age := time.Since(pvc.CreationTimestamp.Time)
+		if age < pendingClaimGracePeriod {
+			continue
+		}
+		class := ""
+		if pvc.Spec.StorageClassName != nil {
+			class = *pvc.Spec.StorageClassName
+		}
+		if waitForConsumer[class] && !consumed[pvc.Namespace+"/"+pvc.Name] {
+			continue
+		}
+		stuck = append(stuck, models.ObjectRef{
+			Kind:      "PersistentVolumeClaim",
+			Namespace: pvc.Namespace,
+			Name:      pvc.Name,
+			Reason:    fmt.Sprintf("Pending for %s, storage class %q", age.Round(time.Minute), class),
+		})
+	}
+
+	details := fmt.Sprintf("%d persistent volume claims, none stuck in Pending", len(pvcs.Items))
+	if len(stuck) > 0 {
+		details = fmt.Sprintf("%d persistent volume claims stuck in Pending for more than %s", len(stuck), pendingClaimGracePeriod)
+	}
+	return models.ResourceCheck{Label: "Pending Claims", Details: details, Outcome: models.OutcomeFor(len(stuck) == 0), Objects: stuck}
+}
+
+// Kubelet stats are read from this many nodes at a time, each bounded by its own
+// timeout. Reading stops volumeStatsMargin before the deadline of the check, so
+// the nodes read until then are still reported.
+const (
+	volumeStatsWorkers = 8
+	volumeStatsTimeout = 10 * time.Second
+	volumeStatsMargin  = 2 * time.Second
 )
 
-func CheckStorage(clientset *kubernetes.Clientset) []models.ResourceCheck {
-	checks := []models.ResourceCheck{}
-	// checks = append(checks, CheckPods(clientset)...)
-	// checks = append(checks, CheckSMFMonitor(clientset)...)
+// errStatsNotRead is the error of the nodes whose stats were not read before the deadline
+var errStatsNotRead = errors.New("not read before the check timed out")
+
+// CheckVolumeUsage compares the used space of every mounted claim with its
+// capacity, reading the kubelet stats of every node. Nodes whose stats cannot be
+// read are reported as warnings, an error when no node can be read.
+func CheckVolumeUsage(ctx context.Context, clients *Clients) []models.ResourceCheck {
+	storage := clients.Config.Storage
+	nodes, err := clients.Snapshot.Nodes(ctx)
+	if err != nil {
+		return []models.ResourceCheck{{Label: "Volume Usage", Details: "Error fetching nodes", Outcome: models.OutcomeError, Error: err}}
+	}
+
+	statsCtx := ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		statsCtx, cancel = context.WithDeadline(ctx, deadline.Add(-volumeStatsMargin))
+		defer cancel()
+	}
+	stats := make([][]k8s.VolumeStats, len(nodes.Items))
+	errs := make([]error, len(nodes.Items))
+	for i := range errs {
+		errs[i] = errStatsNotRead
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(volumeStatsWorkers, len(nodes.Items)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				nodeCtx, cancel := context.WithTimeout(statsCtx, volumeStatsTimeout)
+				stats[i], errs[i] = clients.GetVolumeStats(nodeCtx, nodes.Items[i].Name)
+				cancel()
+			}
+		}()
+	}
+dispatch:
+	for i := range nodes.Items {
+		select {
+		case jobs <- i:
+		case <-statsCtx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	volumes := map[string]k8s.VolumeStats{}
+	unavailable := []models.ObjectRef{}
+	var lastErr error
+	for i, node := range nodes.Items {
+		if errs[i] != nil {
+			unavailable = append(unavailable, models.ObjectRef{Kind: "Node", Name: node.Name, Reason: fmt.Sprintf("kubelet stats unavailable: %v", errs[i])})
+			lastErr = errs[i]
+			continue
+		}
+		for _, volume := range stats[i] {
+			volumes[volume.Namespace+"/"+volume.Claim] = volume
+		}
+	}
+	if len(nodes.Items) > 0 && len(unavailable) == len(nodes.Items) {
+		return []models.ResourceCheck{{
+			Label:   "Volume Usage",
+			Details: "Kubelet stats are not available on any node",
+			Outcome: models.OutcomeError,
+			Error:   lastErr,
+			Objects: unavailable,
+		}}
+	}
+
+	keys := make([]string, 0, len(volumes))
+	for key := range volumes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	outcome := models.OutcomePass
+	full := []models.ObjectRef{}
+	for _, key := range keys {
+		volume := volumes[key]
+		if volume.CapacityBytes == 0 {
+			continue
+		}
+		percent := int(volume.UsedBytes * 100 / volume.CapacityBytes)
+		if percent < storage.VolumeUsageWarning {
+			continue
+		}
+		if percent >= storage.VolumeUsageCritical {
+			outcome = models.OutcomeFail
+		} else if outcome == models.OutcomePass {
+			outcome = models.OutcomeWarn
+		}
+		full = append(full, models.ObjectRef{
+			Kind:      "PersistentVolumeClaim",
+			Namespace: volume.Namespace,
+			Name:      volume.Claim,
+			Reason:    fmt.Sprintf("%d%% used (%d of %d MiB), mounted by %s", percent, volume.UsedBytes>>20, volume.CapacityBytes>>20, volume.Pod),
+		})
+	}
+
+	details := fmt.Sprintf("%d mounted volumes, %d above %d%% usage", len(volumes), len(full), storage.VolumeUsageWarning)
+	if len(unavailable) > 0 {
+		details += fmt.Sprintf(", kubelet stats unavailable on %d of %d nodes", len(unavailable), len(nodes.Items))
+		if outcome == models.OutcomePass {
+			outcome = models.OutcomeWarn
+		}
+		full = append(full, unavailable...)
+	}
+	return []models.ResourceCheck{{Label: "Volume Usage", Details: details, Outcome: outcome, Objects: full}}
+}
+
+// CheckDataStores reports, for every configured data store, whether its stateful
+// sets are ready and the claims of its namespace are bound
+func CheckDataStores(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) []models.ResourceCheck {
+	var checks []models.ResourceCheck
+	for _, store := range cfg.Storage.DataStores {
+		selector, err := labels.Parse(store.Selector)
+		if err != nil {
+			checks = append(checks, models.ResourceCheck{Label: store.Name, Details: "Invalid data store selector", Outcome: models.OutcomeError, Error: err})
+			continue
+		}
+		statefulsets, err := snapshot.StatefulSets(ctx, store.Namespace)
+		if err != nil {
+			checks = append(checks, models.ResourceCheck{Label: store.Name, Details: "Error fetching stateful sets", Outcome: models.OutcomeError, Error: err})
+			continue
+		}
+		pvcs, err := snapshot.PersistentVolumeClaims(ctx, store.Namespace)
+		if err != nil {
+			checks = append(checks, models.ResourceCheck{Label: store.Name, Details: "Error fetching persistent volume claims", Outcome: models.OutcomeError, Error: err})
+			continue
+		}
+
+		found := 0
+		outcome := models.OutcomePass
+		problems := []models.ObjectRef{}
+		for i := range statefulsets.Items {
+			if !selector.Matches(labels.Set(statefulsets.Items[i].Labels)) {
+				continue
+			}
+			found++
+			r := statefulSetRollout(&statefulsets.Items[i])
+			if r.problem == "" {
+				continue
+			}
+			if r.failed {
+				outcome = models.OutcomeFail
+			} else if outcome == models.OutcomePass {
+				outcome = models.OutcomeWarn
+			}
+			ref := r.object
+			ref.Reason = r.String()
+			problems = append(problems, ref)
+		}
+		if found == 0 {
+			checks = append(checks, models.ResourceCheck{
+				Label:   store.Name,
+				Details: fmt.Sprintf("No %s stateful sets found in %s", store.Name, store.Namespace),
+				Outcome: models.OutcomeSkipped,
+			})
+			continue
+		}
+		for _, pvc := range pvcs.Items {
+			if pvc.Status.Phase != v1.ClaimBound {
+				problems = append(problems, models.ObjectRef{Kind: "PersistentVolumeClaim", Namespace: pvc.Namespace, Name: pvc.Name, Reason: string(pvc.Status.Phase)})
+				outcome = models.OutcomeFail
+			}
+		}
+		checks = append(checks, models.ResourceCheck{
+			Label:   store.Name,
+			Details: fmt.Sprintf("%d stateful sets and %d claims in %s, %d not ready or bound", found, len(pvcs.Items), store.Namespace, len(problems)),
+			Outcome: outcome,
+			Objects: problems,
+		})
+	}
 	return checks
 }
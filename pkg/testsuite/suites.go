@@ -0,0 +1,134 @@
+package testsuite
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Suite names accepted on the command line
+const (
+	SuiteK8s     = "k8s"
+	SuiteInfra   = "infra"
+	SuitePaaS    = "paas"
+	SuiteSMF     = "smf"
+	SuiteUPF     = "upf"
+	SuiteStorage = "storage"
+)
+
+// Suite groups related checks
+type Suite struct {
+	Name  string
+	Title string
+}
+
+// suites lists every suite in the order they are shown and run
+var suites = []Suite{
+	{Name: SuiteK8s, Title: "K8s health"},
+	{Name: SuiteInfra, Title: "Infra health"},
+	{Name: SuitePaaS, Title: "PAAS health"},
+	{Name: SuiteSMF, Title: "SMF health"},
+	{Name: SuiteUPF, Title: "UPF health"},
+	{Name: SuiteStorage, Title: "Storage health"},
+}
+
+// registry holds every registered check in registration order
+var registry = []Check{}
+
+// Register adds checks to the registry; suite files call it from init
+func Register(checks ...Check) {
+	for _, check := range checks {
+		if _, ok := LookupSuite(check.Suite()); !ok {
+			panic(fmt.Sprintf("check %s registered for unknown suite %s", check.Name(), check.Suite()))
+		}
+		for _, existing := range registry {
+			if existing.Suite() == check.Suite() && existing.Name() == check.Name() {
+				panic(fmt.Sprintf("check %s/%s registered twice", check.Suite(), check.Name()))
+			}
+		}
+		registry = append(registry, check)
+	}
+}
+
+// Suites returns every suite in display order
+func Suites() []Suite {
+	return suites
+}
+
+// SuiteNames returns the names of every suite in display order
+func SuiteNames() []string {
+	names := []string{}
+	for _, suite := range suites {
+		names = append(names, suite.Name)
+	}
+	return names
+}
+
+// LookupSuite returns the suite with the given name
+func LookupSuite(name string) (Suite, bool) {
+	for _, suite := range suites {
+		if suite.Name == name {
+			return suite, true
+		}
+	}
+	return Suite{}, false
+}
+
+// Filter selects checks from the registry; empty fields match everything
+type Filter struct {
+	Suites []string
+	Names  []string
+	Tags   []string
+}
+
+func (f Filter) matches(check Check) bool {
+	if len(f.Suites) > 0 && !slices.Contains(f.Suites, check.Suite()) {
+		return false
+	}
+	if len(f.Names) > 0 && !slices.Contains(f.Names, check.Name()) && !slices.Contains(f.Names, check.Suite()+"/"+check.Name()) {
+		return false
+	}
+	if len(f.Tags) > 0 {
+		for _, tag := range check.Tags() {
+			if slices.Contains(f.Tags, tag) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Checks returns the registered checks matching the filter, grouped by suite in display order
+func Checks(filter Filter) []Check {
+	order := map[string]int{}
+	for i, suite := range suites {
+		order[suite.Name] = i
+	}
+	checks := []Check{}
+	for _, check := range registry {
+		if filter.matches(check) {
+			checks = append(checks, check)
+		}
+	}
+	sort.SliceStable(checks, func(i, j int) bool {
+		return order[checks[i].Suite()] < order[checks[j].Suite()]
+	})
+	return checks
+}
+
+// Tags returns every tag used by a registered check
+func Tags() []string {
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, check := range registry {
+		for _, tag := range check.Tags() {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -1,14 +1,209 @@
 package testsuite
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"healthctl/pkg/config"
+	"healthctl/pkg/k8s"
 	"healthctl/pkg/models"
 
-	"k8s.io/client-go/kubernetes"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Multus annotations listing the networks a pod asks for and the ones it got
+const (
+	multusNetworksAnnotation = "k8s.v1.cni.cncf.io/networks"
+	multusStatusAnnotation   = "k8s.v1.cni.cncf.io/network-status"
 )
 
-func CheckUPF(clientset *kubernetes.Clientset) []models.ResourceCheck {
-	checks := []models.ResourceCheck{}
-	// checks = append(checks, CheckPods(clientset)...)
-	// checks = append(checks, CheckSMFMonitor(clientset)...)
+func init() {
+	Register(
+		NewCheck(SuiteUPF, "workloads", "All UPF deployments and stateful sets are rolled out with their replicas ready", []string{"workloads", "upf"}, configChecks(CheckUPFWorkloads)),
+		NewCheck(SuiteUPF, "monitor", "Critical services reported by upfmonitor are UP", []string{"monitor", "upf"}, CheckUPFMonitor),
+		NewCheck(SuiteUPF, "interfaces", "N3, N4 and N6 interface pods are scheduled with their Multus networks attached", []string{"network", "upf"}, configChecks(CheckUPFInterfaces)),
+	)
+}
+
+// CheckUPFWorkloads reports the readiness and rollout of every UPF deployment and stateful set
+func CheckUPFWorkloads(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) []models.ResourceCheck {
+	selector, err := labels.Parse(cfg.UPF.Selector)
+	if err != nil {
+		return []models.ResourceCheck{{Label: "UPF workloads", Details: "Invalid UPF selector", Outcome: models.OutcomeError, Error: err}}
+	}
+	deployments, err := snapshot.Deployments(ctx, cfg.UPF.Namespace)
+	if err != nil {
+		return []models.ResourceCheck{{Label: "UPF workloads", Details: "Error fetching deployments", Outcome: models.OutcomeError, Error: err}}
+	}
+	statefulsets, err := snapshot.StatefulSets(ctx, cfg.UPF.Namespace)
+	if err != nil {
+		return []models.ResourceCheck{{Label: "UPF workloads", Details: "Error fetching stateful sets", Outcome: models.OutcomeError, Error: err}}
+	}
+
+	var checks []models.ResourceCheck
+	workload := func(r rollout) {
+		check := models.ResourceCheck{
+			Label:   r.object.Name,
+			Details: fmt.Sprintf("%s %s: ready %d/%d", r.object.Kind, r.object.Name, r.ready, r.desired),
+			Outcome: r.outcome(),
+		}
+		if r.problem != "" {
+			check.Details = fmt.Sprintf("%s %s: %s", r.object.Kind, r.object.Name, r)
+			ref := r.object
+			ref.Reason = r.String()
+			check.Objects = []models.ObjectRef{ref}
+		}
+		checks = append(checks, check)
+	}
+	for i := range deployments.Items {
+		if selector.Matches(labels.Set(deployments.Items[i].Labels)) {
+			workload(deploymentRollout(&deployments.Items[i]))
+		}
+	}
+	for i := range statefulsets.Items {
+		if selector.Matches(labels.Set(statefulsets.Items[i].Labels)) {
+			workload(statefulSetRollout(&statefulsets.Items[i]))
+		}
+	}
+
+	if len(checks) == 0 {
+		return append(checks, models.ResourceCheck{
+			Label:    "UPF workloads",
+			Details:  fmt.Sprintf("No UPF deployments or stateful sets found in %s", cfg.UPF.Namespace),
+			Outcome:  models.OutcomeFail,
+			Severity: models.SeverityCritical,
+		})
+	}
+	return checks
+}
+
+// CheckUPFMonitor reads the critical services from the upfmonitor endpoint,
+// querying it from inside the upfmonitor pod with the clients of the run
+func CheckUPFMonitor(ctx context.Context, clients *Clients) []models.ResourceCheck {
+	upf := clients.Config.UPF
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", upf.Monitor.Port, upf.Monitor.Path)
+	return queryMonitor(ctx, clients, "UPF Monitor", upf.Namespace, upf.Monitor.Selector, upf.Monitor.Container, url)
+}
+
+// CheckUPFInterfaces reports, for every configured interface, whether its pods are
+// scheduled and running with the Multus network requested and attached
+func CheckUPFInterfaces(ctx context.Context, snapshot *k8s.Snapshot, cfg *config.Config) []models.ResourceCheck {
+	var checks []models.ResourceCheck
+	for _, iface := range cfg.UPF.Interfaces {
+		label := iface.Name + " interface"
+		pods, err := snapshot.PodsWithLabels(ctx, cfg.UPF.Namespace, iface.Selector)
+		if err != nil {
+			checks = append(checks, models.ResourceCheck{Label: label, Details: fmt.Sprintf("Error fetching %s pods", iface.Selector), Outcome: models.OutcomeError, Error: err})
+			continue
+		}
+		if len(pods.Items) == 0 {
+			checks = append(checks, models.ResourceCheck{
+				Label:    label,
+				Details:  fmt.Sprintf("No %s pods found in %s for network %s", iface.Selector, cfg.UPF.Namespace, iface.Network),
+				Outcome:  models.OutcomeFail,
+				Severity: models.SeverityCritical,
+			})
+			continue
+		}
+
+		failed := []models.ObjectRef{}
+		for _, pod := range pods.Items {
+			if reason := upfInterfaceProblem(&pod, iface.Network); reason != "" {
+				failed = append(failed, models.ObjectRef{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name, Reason: reason})
+			}
+		}
+		details := fmt.Sprintf("%d/%d pods attached to %s", len(pods.Items)-len(failed), len(pods.Items), iface.Network)
+		checks = append(checks, models.ResourceCheck{
+			Label:    label,
+			Details:  details,
+			Outcome:  models.OutcomeFor(len(failed) == 0),
+			Severity: models.SeverityCritical,
+			Objects:  failed,
+		})
+	}
 	return checks
 }
+
+// upfInterfaceProblem returns why the pod does not serve the interface on the
+// network, or an empty string when it does
+func upfInterfaceProblem(pod *v1.Pod, network string) string {
+	if pod.Spec.NodeName == "" {
+		return "not scheduled"
+	}
+	if pod.Status.Phase != v1.PodRunning {
+		return podFailureReason(pod)
+	}
+	requested := false
+	for _, name := range requestedNetworks(pod) {
+		if sameNetwork(name, network, pod.Namespace) {
+			requested = true
+		}
+	}
+	if !requested {
+		return fmt.Sprintf("network %s not requested in %s", network, multusNetworksAnnotation)
+	}
+
+	var statuses []struct {
+		Name      string   `json:"name"`
+		Interface string   `json:"interface"`
+		IPs       []string `json:"ips"`
+	}
+	if err := json.Unmarshal([]byte(pod.Annotations[multusStatusAnnotation]), &statuses); err != nil {
+		return fmt.Sprintf("no readable %s annotation", multusStatusAnnotation)
+	}
+	for _, status := range statuses {
+		if sameNetwork(status.Name, network, pod.Namespace) {
+			if len(status.IPs) == 0 {
+				return fmt.Sprintf("network %s attached as %s without an IP", network, status.Interface)
+			}
+			return ""
+		}
+	}
+	return fmt.Sprintf("network %s not attached", network)
+}
+
+// requestedNetworks returns the networks of the Multus networks annotation, which
+// is either a comma separated list of [namespace/]name[@interface] or a JSON list
+func requestedNetworks(pod *v1.Pod) []string {
+	value := strings.TrimSpace(pod.Annotations[multusNetworksAnnotation])
+	names := []string{}
+	if strings.HasPrefix(value, "[") {
+		var selections []struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		}
+		if err := json.Unmarshal([]byte(value), &selections); err != nil {
+			return names
+		}
+		for _, selection := range selections {
+			if selection.Namespace != "" {
+				names = append(names, selection.Namespace+"/"+selection.Name)
+			} else {
+				names = append(names, selection.Name)
+			}
+		}
+		return names
+	}
+	for _, item := range strings.Split(value, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(item), "@")
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// sameNetwork compares network names given as name or namespace/name, a missing
+// namespace is the namespace of the pod
+func sameNetwork(a, b, namespace string) bool {
+	qualify := func(name string) string {
+		if strings.Contains(name, "/") {
+			return name
+		}
+		return namespace + "/" + name
+	}
+	return qualify(a) == qualify(b)
+}
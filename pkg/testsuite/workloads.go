@@ -0,0 +1,212 @@
+package testsuite
+
+import (
+	"fmt"
+	"strings"
+
+	"healthctl/pkg/models"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// revisionAnnotation holds the rollout revision of deployments and their replica sets
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// maxWorkloadSummary is the number of unhealthy workloads named in the details, the rest are counted
+const maxWorkloadSummary = 3
+
+// rollout is the replica counts of a workload and what is wrong with it
+type rollout struct {
+	object                             models.ObjectRef
+	desired, ready, updated, available int32
+	// problem is empty for healthy workloads, message explains it further in the object reason
+	problem string
+	message string
+	// failed is false for problems that are only warnings, such as a rollout in progress
+	failed bool
+}
+
+func (r rollout) String() string {
+	s := fmt.Sprintf("desired %d, ready %d, updated %d, available %d: %s", r.desired, r.ready, r.updated, r.available, r.problem)
+	if r.message != "" {
+		s += ", " + r.message
+	}
+	return s
+}
+
+// outcome is pass for healthy workloads, fail for failed rollouts and warn for the others
+func (r rollout) outcome() models.Outcome {
+	switch {
+	case r.problem == "":
+		return models.OutcomePass
+	case r.failed:
+		return models.OutcomeFail
+	}
+	return models.OutcomeWarn
+}
+
+// replicas returns the desired replicas, which default to 1 when not set
+func replicas(desired *int32) int32 {
+	if desired == nil {
+		return 1
+	}
+	return *desired
+}
+
+// deploymentRollout reports stalled rollouts, rollouts in progress and missing replicas
+func deploymentRollout(deploy *appsv1.Deployment) rollout {
+	r := rollout{
+		object:    models.ObjectRef{Kind: "Deployment", Namespace: deploy.Namespace, Name: deploy.Name},
+		desired:   replicas(deploy.Spec.Replicas),
+		ready:     deploy.Status.ReadyReplicas,
+		updated:   deploy.Status.UpdatedReplicas,
+		available: deploy.Status.AvailableReplicas,
+	}
+	for _, condition := range deploy.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Status == v1.ConditionFalse {
+			r.problem = fmt.Sprintf("rollout stalled (%s)", condition.Reason)
+			r.message = condition.Message
+			r.failed = true
+			return r
+		}
+	}
+	inProgress := deploy.Status.ObservedGeneration < deploy.Generation || r.updated < r.desired || deploy.Status.Replicas > r.updated
+	switch {
+	case inProgress && deploy.Spec.Paused:
+		r.problem = "rollout paused"
+	case inProgress:
+		r.problem = "rollout in progress"
+	case r.ready < r.desired || r.available < r.desired:
+		r.problem = "replicas not ready"
+		r.failed = true
+	}
+	return r
+}
+
+// statefulSetRollout reports missing replicas and rollouts in progress. Stateful sets
+// have no progress deadline, so a rollout leaving replicas unready is a failure.
+func statefulSetRollout(ss *appsv1.StatefulSet) rollout {
+	r := rollout{
+		object:    models.ObjectRef{Kind: "StatefulSet", Namespace: ss.Namespace, Name: ss.Name},
+		desired:   replicas(ss.Spec.Replicas),
+		ready:     ss.Status.ReadyReplicas,
+		updated:   ss.Status.UpdatedReplicas,
+		available: ss.Status.AvailableReplicas,
+	}
+	rolling := ss.Spec.UpdateStrategy.Type != appsv1.OnDeleteStatefulSetStrategyType
+	inProgress := ss.Status.ObservedGeneration < ss.Generation ||
+		(rolling && ss.Status.UpdateRevision != "" && ss.Status.CurrentRevision != ss.Status.UpdateRevision)
+	// a partitioned rollout only updates the replicas from the partition ordinal up and
+	// never converges the revisions, it is done once those replicas are updated
+	if update := ss.Spec.UpdateStrategy.RollingUpdate; rolling && update != nil && update.Partition != nil && *update.Partition > 0 {
+		inProgress = ss.Status.ObservedGeneration < ss.Generation || r.updated < max(r.desired-*update.Partition, 0)
+	}
+	switch {
+	case r.ready < r.desired && inProgress:
+		r.problem = fmt.Sprintf("rollout to %s not progressing, replicas not ready", ss.Status.UpdateRevision)
+		r.failed = true
+	case r.ready < r.desired:
+		r.problem = "replicas not ready"
+		r.failed = true
+	case inProgress:
+		r.problem = "rollout in progress"
+	}
+	return r
+}
+
+// daemonSetRollout reports nodes missing their pod, unready pods and rollouts in progress
+func daemonSetRollout(ds *appsv1.DaemonSet) rollout {
+	r := rollout{
+		object:    models.ObjectRef{Kind: "DaemonSet", Namespace: ds.Namespace, Name: ds.Name},
+		desired:   ds.Status.DesiredNumberScheduled,
+		ready:     ds.Status.NumberReady,
+		updated:   ds.Status.UpdatedNumberScheduled,
+		available: ds.Status.NumberAvailable,
+	}
+	inProgress := ds.Status.ObservedGeneration < ds.Generation || r.updated < r.desired
+	switch {
+	case ds.Status.CurrentNumberScheduled < r.desired:
+		r.problem = fmt.Sprintf("scheduled on %d of %d nodes", ds.Status.CurrentNumberScheduled, r.desired)
+		r.failed = true
+	case r.ready < r.desired:
+		r.problem = "pods not ready"
+		r.failed = true
+	case ds.Status.NumberMisscheduled > 0:
+		r.problem = fmt.Sprintf("%d pods running on nodes they should not run on", ds.Status.NumberMisscheduled)
+	case inProgress:
+		r.problem = "rollout in progress"
+	}
+	return r
+}
+
+// replicaSetRollout reports replica sets with missing replicas
+func replicaSetRollout(rs *appsv1.ReplicaSet) rollout {
+	// every replica of a replica set runs its own template, so all of them are updated
+	r := rollout{
+		object:    models.ObjectRef{Kind: "ReplicaSet", Namespace: rs.Namespace, Name: rs.Name},
+		desired:   replicas(rs.Spec.Replicas),
+		ready:     rs.Status.ReadyReplicas,
+		updated:   rs.Status.Replicas,
+		available: rs.Status.AvailableReplicas,
+	}
+	if r.ready < r.desired || r.available < r.desired {
+		r.problem = "replicas not ready"
+		r.failed = true
+	}
+	return r
+}
+
+// supersededReplicaSet reports whether the replica set is an old revision of its
+// deployment, which is scaled down by the rollout and checked through the deployment
+func supersededReplicaSet(rs *appsv1.ReplicaSet, deployments map[string]*appsv1.Deployment) bool {
+	for _, owner := range rs.OwnerReferences {
+		if owner.Kind != "Deployment" || owner.Controller == nil || !*owner.Controller {
+			continue
+		}
+		deploy, ok := deployments[rs.Namespace+"/"+owner.Name]
+		if !ok {
+			return false
+		}
+		return rs.Annotations[revisionAnnotation] != deploy.Annotations[revisionAnnotation]
+	}
+	return false
+}
+
+// rolloutResult summarizes the rollouts of the workloads of one kind into a single
+// result naming every unhealthy workload, plural names the kind in the details
+func rolloutResult(label, plural string, rollouts []rollout) models.ResourceCheck {
+	total := len(rollouts)
+	if total == 0 {
+		return models.ResourceCheck{Label: label, Details: fmt.Sprintf("No %s are available.", plural), Outcome: models.OutcomeFail}
+	}
+	outcome := models.OutcomePass
+	unhealthy := []models.ObjectRef{}
+	summary := []string{}
+	for _, r := range rollouts {
+		if r.problem == "" {
+			continue
+		}
+		if r.failed {
+			outcome = models.OutcomeFail
+		} else if outcome == models.OutcomePass {
+			outcome = models.OutcomeWarn
+		}
+		ref := r.object
+		ref.Reason = r.String()
+		unhealthy = append(unhealthy, ref)
+		summary = append(summary, fmt.Sprintf("%s/%s (%s, ready %d/%d)", ref.Namespace, ref.Name, r.problem, r.ready, r.desired))
+	}
+	if len(unhealthy) == 0 {
+		return models.ResourceCheck{Label: label, Details: fmt.Sprintf("All %d %s are healthy.", total, plural), Outcome: models.OutcomePass}
+	}
+	if len(summary) > maxWorkloadSummary {
+		summary = append(summary[:maxWorkloadSummary], fmt.Sprintf("and %d more", len(summary)-maxWorkloadSummary))
+	}
+	return models.ResourceCheck{
+		Label:   label,
+		Details: fmt.Sprintf("%d of %d %s not healthy: %s", len(unhealthy), total, plural, strings.Join(summary, "; ")),
+		Outcome: outcome,
+		Objects: unhealthy,
+	}
+}
@@ -0,0 +1,224 @@
+package testsuite
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func int32Ptr(n int32) *int32 {
+	return &n
+}
+
+// testDeployment returns a deployment of 3 replicas at generation 2, rolled out
+// and with all replicas ready unless changed by update
+func testDeployment(update func(*appsv1.Deployment)) *appsv1.Deployment {
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default", Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(3)},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           3,
+			ReadyReplicas:      3,
+			UpdatedReplicas:    3,
+			AvailableReplicas:  3,
+		},
+	}
+	if update != nil {
+		update(deploy)
+	}
+	return deploy
+}
+
+func TestDeploymentRollout(t *testing.T) {
+	tests := []struct {
+		name    string
+		deploy  *appsv1.Deployment
+		problem string
+		failed  bool
+	}{
+		{name: "healthy", deploy: testDeployment(nil)},
+		{
+			name: "progress deadline exceeded",
+			deploy: testDeployment(func(d *appsv1.Deployment) {
+				d.Status.UpdatedReplicas = 1
+				d.Status.Conditions = []appsv1.DeploymentCondition{{
+					Type:    appsv1.DeploymentProgressing,
+					Status:  v1.ConditionFalse,
+					Reason:  "ProgressDeadlineExceeded",
+					Message: `ReplicaSet "api-5d9" has timed out progressing.`,
+				}}
+			}),
+			problem: "rollout stalled (ProgressDeadlineExceeded)",
+			failed:  true,
+		},
+		{
+			name: "rollout in progress",
+			deploy: testDeployment(func(d *appsv1.Deployment) {
+				d.Status.Replicas = 4
+				d.Status.UpdatedReplicas = 1
+				d.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentProgressing, Status: v1.ConditionTrue, Reason: "ReplicaSetUpdated"}}
+			}),
+			problem: "rollout in progress",
+		},
+		{
+			name:    "generation not observed yet",
+			deploy:  testDeployment(func(d *appsv1.Deployment) { d.Generation = 3 }),
+			problem: "rollout in progress",
+		},
+		{
+			name: "paused rollout",
+			deploy: testDeployment(func(d *appsv1.Deployment) {
+				d.Spec.Paused = true
+				d.Status.UpdatedReplicas = 1
+			}),
+			problem: "rollout paused",
+		},
+		{
+			name:    "replicas not ready",
+			deploy:  testDeployment(func(d *appsv1.Deployment) { d.Status.ReadyReplicas = 2 }),
+			problem: "replicas not ready",
+			failed:  true,
+		},
+		{
+			name: "default of one replica",
+			deploy: testDeployment(func(d *appsv1.Deployment) {
+				d.Spec.Replicas = nil
+				d.Status = appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 1, ReadyReplicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}
+			}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := deploymentRollout(tt.deploy)
+			if r.problem != tt.problem || r.failed != tt.failed {
+				t.Errorf("deploymentRollout() = %q, failed %v, want %q, failed %v", r.problem, r.failed, tt.problem, tt.failed)
+			}
+		})
+	}
+}
+
+// testStatefulSet returns a stateful set of 3 replicas rolled out to revision web-2
+// with all replicas ready, unless changed by update
+func testStatefulSet(update func(*appsv1.StatefulSet)) *appsv1.StatefulSet {
+	ss := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Generation: 2},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:       int32Ptr(3),
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType},
+		},
+		Status: appsv1.StatefulSetStatus{
+			ObservedGeneration: 2,
+			Replicas:           3,
+			ReadyReplicas:      3,
+			UpdatedReplicas:    3,
+			AvailableReplicas:  3,
+			CurrentRevision:    "web-2",
+			UpdateRevision:     "web-2",
+		},
+	}
+	if update != nil {
+		update(ss)
+	}
+	return ss
+}
+
+// partitioned updates the stateful set to a rollout to web-3 of the replicas from
+// the partition ordinal up, with updated of them done
+func partitioned(partition, updated int32) func(*appsv1.StatefulSet) {
+	return func(ss *appsv1.StatefulSet) {
+		ss.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{Partition: int32Ptr(partition)}
+		ss.Status.UpdateRevision = "web-3"
+		ss.Status.UpdatedReplicas = updated
+	}
+}
+
+func TestStatefulSetRollout(t *testing.T) {
+	tests := []struct {
+		name    string
+		ss      *appsv1.StatefulSet
+		problem string
+		failed  bool
+	}{
+		{name: "healthy", ss: testStatefulSet(nil)},
+		{
+			name: "rollout in progress",
+			ss: testStatefulSet(func(ss *appsv1.StatefulSet) {
+				ss.Status.UpdateRevision = "web-3"
+				ss.Status.UpdatedReplicas = 1
+			}),
+			problem: "rollout in progress",
+		},
+		{
+			name: "rollout leaving replicas unready",
+			ss: testStatefulSet(func(ss *appsv1.StatefulSet) {
+				ss.Status.UpdateRevision = "web-3"
+				ss.Status.UpdatedReplicas = 1
+				ss.Status.ReadyReplicas = 2
+			}),
+			problem: "rollout to web-3 not progressing, replicas not ready",
+			failed:  true,
+		},
+		{name: "partition rolled out", ss: testStatefulSet(partitioned(2, 1))},
+		{name: "partition rolling out", ss: testStatefulSet(partitioned(1, 1)), problem: "rollout in progress"},
+		{name: "partition above the replicas", ss: testStatefulSet(partitioned(5, 0))},
+		{
+			name: "on delete strategy",
+			ss: testStatefulSet(func(ss *appsv1.StatefulSet) {
+				ss.Spec.UpdateStrategy.Type = appsv1.OnDeleteStatefulSetStrategyType
+				ss.Status.UpdateRevision = "web-3"
+				ss.Status.UpdatedReplicas = 0
+			}),
+		},
+		{
+			name:    "replicas not ready",
+			ss:      testStatefulSet(func(ss *appsv1.StatefulSet) { ss.Status.ReadyReplicas = 1 }),
+			problem: "replicas not ready",
+			failed:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := statefulSetRollout(tt.ss)
+			if r.problem != tt.problem || r.failed != tt.failed {
+				t.Errorf("statefulSetRollout() = %q, failed %v, want %q, failed %v", r.problem, r.failed, tt.problem, tt.failed)
+			}
+		})
+	}
+}
+
+func TestSupersededReplicaSet(t *testing.T) {
+	controller := true
+	deployments := map[string]*appsv1.Deployment{
+		"default/api": {ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default", Annotations: map[string]string{revisionAnnotation: "4"}}},
+	}
+	replicaSet := func(revision string, owner *metav1.OwnerReference) *appsv1.ReplicaSet {
+		rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "api-" + revision, Namespace: "default", Annotations: map[string]string{revisionAnnotation: revision}}}
+		if owner != nil {
+			rs.OwnerReferences = []metav1.OwnerReference{*owner}
+		}
+		return rs
+	}
+	owner := &metav1.OwnerReference{Kind: "Deployment", Name: "api", Controller: &controller}
+
+	tests := []struct {
+		name string
+		rs   *appsv1.ReplicaSet
+		want bool
+	}{
+		{name: "current revision", rs: replicaSet("4", owner)},
+		{name: "old revision", rs: replicaSet("3", owner), want: true},
+		{name: "no owner", rs: replicaSet("3", nil)},
+		{name: "owner not a controller", rs: replicaSet("3", &metav1.OwnerReference{Kind: "Deployment", Name: "api"})},
+		{name: "unknown deployment", rs: replicaSet("3", &metav1.OwnerReference{Kind: "Deployment", Name: "web", Controller: &controller})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := supersededReplicaSet(tt.rs, deployments); got != tt.want {
+				t.Errorf("supersededReplicaSet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}